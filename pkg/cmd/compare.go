@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/ssm"
+)
+
+// secretDiff lists the keys that differ between two sets of secrets, sorted.
+type secretDiff struct {
+	added   []string
+	removed []string
+	changed []string
+}
+
+// empty reports whether the two sets of secrets compared were identical.
+func (d secretDiff) empty() bool {
+	return len(d.added) == 0 && len(d.removed) == 0 && len(d.changed) == 0
+}
+
+// compareSecrets compares secrets from with secrets to. Keys only in to are added and
+// keys only in from are removed.
+func compareSecrets(from, to map[string]string) secretDiff {
+	var d secretDiff
+	for k, v := range from {
+		tv, ok := to[k]
+		if !ok {
+			d.removed = append(d.removed, k)
+		} else if tv != v {
+			d.changed = append(d.changed, k)
+		}
+	}
+	for k := range to {
+		if _, ok := from[k]; !ok {
+			d.added = append(d.added, k)
+		}
+	}
+	sort.Strings(d.added)
+	sort.Strings(d.removed)
+	sort.Strings(d.changed)
+	return d
+}
+
+// ssmSecrets reads the parameters stored under the ssm path along with their secrets,
+// decoding them when asked.
+func (c *CommandOptions) ssmSecrets(ctx context.Context) (ssm.Parameters, map[string]string, error) {
+	params, err := c.ssm.GetSecrets(ctx, c.ssmPath, c.getOptions())
+	if err != nil {
+		return nil, nil, err
+	}
+	secrets := params.Secrets()
+	if c.encode {
+		secrets, err = c.ssm.DecodeSecrets(secrets)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return params, secrets, nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareSecrets(t *testing.T) {
+
+	cases := []struct {
+		name string
+		from map[string]string
+		to   map[string]string
+		want secretDiff
+	}{
+		{
+			name: "identical secrets",
+			from: map[string]string{"user": "admin", "passwd": "x"},
+			to:   map[string]string{"user": "admin", "passwd": "x"},
+			want: secretDiff{},
+		},
+		{
+			name: "added keys",
+			from: map[string]string{"user": "admin"},
+			to:   map[string]string{"user": "admin", "passwd": "x", "host": "db"},
+			want: secretDiff{added: []string{"host", "passwd"}},
+		},
+		{
+			name: "removed keys",
+			from: map[string]string{"user": "admin", "passwd": "x", "host": "db"},
+			to:   map[string]string{"user": "admin"},
+			want: secretDiff{removed: []string{"host", "passwd"}},
+		},
+		{
+			name: "changed keys",
+			from: map[string]string{"user": "admin", "passwd": "x", "host": "db"},
+			to:   map[string]string{"user": "admin", "passwd": "y", "host": ""},
+			want: secretDiff{changed: []string{"host", "passwd"}},
+		},
+		{
+			name: "added, removed and changed keys",
+			from: map[string]string{"user": "admin", "passwd": "x"},
+			to:   map[string]string{"passwd": "y", "host": "db"},
+			want: secretDiff{added: []string{"host"}, removed: []string{"user"}, changed: []string{"passwd"}},
+		},
+		{
+			name: "nil from adds every key",
+			from: nil,
+			to:   map[string]string{"user": "admin", "passwd": "x"},
+			want: secretDiff{added: []string{"passwd", "user"}},
+		},
+		{
+			name: "nil from and to are identical",
+			want: secretDiff{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run("test compareSecrets with "+tc.name, func(t *testing.T) {
+			d := compareSecrets(tc.from, tc.to)
+			assert.Equal(t, tc.want, d)
+			assert.Equal(t, len(tc.want.added)+len(tc.want.removed)+len(tc.want.changed) == 0, d.empty())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/ssm"
+)
+
+var copyCmd = &cobra.Command{
+	Use:          "copy",
+	Short:        "copy or move the parameters under an ssm parameter store path to another path, region or account",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		return cli.Copy(c.Context())
+	},
+}
+
+func (c *CommandOptions) Copy(ctx context.Context) error {
+
+	// a destination in the current region and account is the source client, so that
+	// copying a path onto itself is refused
+	dest := c.ssm
+	sameRegion := len(c.toRegion) == 0 || c.toRegion == c.ssm.Region()
+	if !sameRegion || len(c.toProfile) > 0 || len(c.toRoleArn) > 0 {
+		var err error
+		dest, err = ssm.NewWithOptions(ssm.SessionOptions{
+			Region:  c.toRegion,
+			Profile: c.toProfile,
+			RoleArn: c.toRoleArn,
+		})
+		if err != nil {
+			return fmt.Errorf("error: cannot create aws ssm client for the destination: %s", err)
+		}
+	}
+	copied, err := c.ssm.CopySecrets(ctx, c.copyFrom, dest, c.copyTo, ssm.CopyOptions{
+		Recursive: c.recursive,
+		KeyScheme: c.keyScheme,
+		Keys:      c.keys,
+		KeyId:     c.kmsKeyId,
+		Overwrite: c.overwrite,
+	})
+	if err != nil {
+		return err
+	}
+	if !c.move {
+		return nil
+	}
+	all, err := c.ssm.ParameterNames(ctx, c.copyFrom, c.recursive)
+	if err != nil {
+		return err
+	}
+	return c.ssm.DeleteSecrets(ctx, ssm.WithChunks(copied, all))
+}
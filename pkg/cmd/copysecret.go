@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/k8s"
+)
+
+var copySecretCmd = &cobra.Command{
+	Use:          "copy-secret",
+	Short:        "copy a kubernetes secret to another namespace or cluster",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("error: no secret name provided")
+		}
+		return cli.CopySecret(args, c.Flags().Changed("namespace"))
+	},
+}
+
+// CopySecret copies a secret between contexts and namespaces. The source namespace is
+// the --namespace flag, or the default namespace of the source context when not given.
+func (c *CommandOptions) CopySecret(args []string, namespaceSet bool) error {
+
+	c.SetNamespace()
+	from := c.k8s
+	if len(c.fromContext) > 0 {
+		var err error
+		from, err = contextClient(c.fromContext)
+		if err != nil {
+			return err
+		}
+		if namespaceSet {
+			from.SetNamespace(c.namespace)
+		}
+	}
+	to := from
+	if len(c.toContext) > 0 {
+		var err error
+		to, err = contextClient(c.toContext)
+		if err != nil {
+			return err
+		}
+	}
+	to = to.WithNamespace(c.toNamespace)
+
+	secretname := args[0]
+	destname := secretname
+	if len(c.rename) > 0 {
+		destname = c.rename
+	}
+	if to.GetCluster() == from.GetCluster() && to.GetNamespace() == from.GetNamespace() && destname == secretname {
+		return fmt.Errorf("error: cannot copy secret %s to itself, use --to-context, --to-namespace or --rename", secretname)
+	}
+	err := from.CopySecret(secretname, to, destname, k8s.CopyOptions{
+		Labels:      c.keepLabels,
+		Annotations: c.keepAnnotations,
+		Overwrite:   c.overwrite,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("copied secret: %s/%s to %s/%s\n", from.GetNamespace(), secretname, to.GetNamespace(), destname)
+	return nil
+}
+
+// contextClient returns a kubernetes client for the named kubeconfig context.
+func contextClient(kubecontext string) (*k8s.K8sClient, error) {
+	config, err := k8s.NewK8sConfigForContext(kubecontext)
+	if err != nil {
+		return nil, fmt.Errorf("error: cannot configure k8s client for context %s: %s", kubecontext, err)
+	}
+	return k8s.NewK8sClientFromConfig(config)
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:          "delete",
+	Short:        "delete kubernetes secrets and the parameters under an ssm parameter store path",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) < 1 && len(cli.ssmPath) == 0 {
+			return fmt.Errorf("error: no secret name or --ssm-path provided")
+		}
+		return cli.Delete(c.Context(), args)
+	},
+}
+
+func (c *CommandOptions) Delete(ctx context.Context, args []string) error {
+
+	c.SetNamespace()
+	var names []string
+	if len(c.ssmPath) > 0 {
+		var err error
+		names, err = c.ssm.ParameterNames(ctx, c.ssmPath, c.recursive)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return fmt.Errorf(fmt.Sprintf("no parameters found at path: %s", c.ssmPath))
+		}
+	}
+	for _, secretname := range args {
+		secrets, err := c.k8s.GetSecret(secretname)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  - k8s:%s/%s (%d keys)\n", c.namespace, secretname, len(secrets))
+	}
+	for _, name := range names {
+		fmt.Printf("  - ssm:%s\n", name)
+	}
+	fmt.Printf("%d secrets and %d parameters to delete\n", len(args), len(names))
+
+	if c.dryRun {
+		return nil
+	}
+	if !c.yes {
+		ok, err := confirm("delete these secrets and parameters?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("delete cancelled")
+		}
+	}
+
+	for _, secretname := range args {
+		if err := c.k8s.DeleteSecret(secretname); err != nil {
+			return err
+		}
+		fmt.Printf("deleted secret: %s\n", secretname)
+	}
+	return c.ssm.DeleteSecrets(ctx, names)
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// diff exit codes, so that scripts can tell drift apart from failure
+const (
+	diffIdentical = 0
+	diffDiffers   = 1
+	diffError     = 2
+)
+
+var diffCmd = &cobra.Command{
+	Use:          "diff",
+	Short:        "compare a kubernetes secret with the parameters under an ssm parameter store path",
+	SilenceUsage: true,
+	PersistentPreRunE: func(c *cobra.Command, args []string) error {
+		if cli.setupErr != nil {
+			return &exitError{code: diffError, err: cli.setupErr}
+		}
+		return nil
+	},
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return &exitError{code: diffError, err: fmt.Errorf("error: no secret name provided")}
+		}
+		if len(cli.ssmPath) == 0 {
+			return &exitError{code: diffError, err: fmt.Errorf("error: --ssm-path is required")}
+		}
+		differs, err := cli.Diff(c.Context(), args)
+		if err != nil {
+			return &exitError{code: diffError, err: err}
+		}
+		if differs {
+			c.SilenceErrors = true
+			return &exitError{code: diffDiffers, err: fmt.Errorf("secret %s differs from %s", args[0], cli.ssmPath)}
+		}
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return &exitError{code: diffError, err: err}
+	})
+}
+
+// Diff prints the keys added, removed and changed in the ssm path relative to the
+// kubernetes secret, and reports whether there were any.
+func (c *CommandOptions) Diff(ctx context.Context, args []string) (bool, error) {
+
+	c.SetNamespace()
+	secretname := args[0]
+	secrets, err := c.k8s.GetSecret(secretname)
+	if err != nil {
+		return false, err
+	}
+	_, params, err := c.ssmSecrets(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	mode := valuesHash
+	if c.showValues {
+		mode = valuesPlain
+	}
+	d := compareSecrets(secrets, params)
+	fmt.Printf("--- k8s:%s/%s\n+++ ssm:%s\n", c.namespace, secretname, c.ssmPath)
+	for _, k := range d.removed {
+		fmt.Printf("- %s: %s\n", k, displayValue(secrets[k], mode))
+	}
+	for _, k := range d.added {
+		fmt.Printf("+ %s: %s\n", k, displayValue(params[k], mode))
+	}
+	for _, k := range d.changed {
+		fmt.Printf("~ %s: %s -> %s\n", k, displayValue(secrets[k], mode), displayValue(params[k], mode))
+	}
+	if d.empty() {
+		fmt.Printf("identical\n")
+		return false, nil
+	}
+	fmt.Printf("%d added, %d removed, %d changed\n", len(d.added), len(d.removed), len(d.changed))
+	return true, nil
+}
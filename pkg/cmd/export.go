@@ -2,14 +2,31 @@ package cmd
 
 import (
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/k8s"
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/ssm"
+)
+
+// placeholders expanded in the --path-layout of a bulk export
+const (
+	layoutNamespace = "{namespace}"
+	layoutSecret    = "{secret}"
 )
 
 var exportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "export a kubernetes secret to aws ssm param store",
 	RunE: func(c *cobra.Command, args []string) error {
+		if len(cli.selector) > 0 || cli.all || cli.allNamespaces {
+			if len(args) > 0 {
+				return fmt.Errorf("error: a secret name cannot be used with --selector, --all or --all-namespaces")
+			}
+			return cli.ExportAll()
+		}
 		if len(args) < 1 {
 			return fmt.Errorf("error: no secret name provided")
 		}
@@ -20,26 +37,98 @@ var exportCmd = &cobra.Command{
 func (c *CommandOptions) Export(args []string) error {
 
 	c.SetNamespace()
+	opts, err := c.putOptions()
+	if err != nil {
+		return err
+	}
 	secretname := args[0]
-	secrets, err := c.k8s.GetSecret(secretname)
+	if _, err := c.exportSecret(c.k8s, secretname, c.ssmPath, opts); err != nil {
+		return err
+	}
+	fmt.Printf("exported secret: %s\n", secretname)
+	return nil
+}
+
+// ExportAll exports every secret matching the label selector, or every secret with --all,
+// each to its own path below the ssm path laid out by --path-layout. A secret that fails
+// to export is reported and the remaining secrets are still exported.
+func (c *CommandOptions) ExportAll() error {
+
+	c.SetNamespace()
+	if err := validateLayout(c.pathLayout, c.allNamespaces); err != nil {
+		return err
+	}
+	opts, err := c.putOptions()
 	if err != nil {
 		return err
 	}
+	refs, err := c.k8s.ListSecrets(c.selector, c.allNamespaces)
+	if err != nil {
+		return err
+	}
+	if len(refs) == 0 {
+		if len(c.selector) > 0 {
+			return fmt.Errorf("no secrets found matching selector: %s", c.selector)
+		}
+		return fmt.Errorf("no secrets found")
+	}
+	failed := 0
+	for _, ref := range refs {
+		parampath := exportPath(c.ssmPath, c.pathLayout, ref)
+		keys, err := c.exportSecret(c.k8s.WithNamespace(ref.Namespace), ref.Name, parampath, opts)
+		if err != nil {
+			failed++
+			fmt.Printf("failed to export secret: %s/%s: %s\n", ref.Namespace, ref.Name, err)
+			continue
+		}
+		fmt.Printf("exported secret: %s/%s, path: %s, keys: %d\n", ref.Namespace, ref.Name, parampath, keys)
+	}
+	fmt.Printf("exported %d of %d secrets\n", len(refs)-failed, len(refs))
+	if failed > 0 {
+		return fmt.Errorf("error: %d secrets failed to export", failed)
+	}
+	return nil
+}
+
+// exportSecret writes the data of a secret to parameter store under parampath and
+// returns the number of keys written.
+func (c *CommandOptions) exportSecret(kclient *k8s.K8sClient, secretname string, parampath string, opts ssm.PutOptions) (int, error) {
+	secrets, err := kclient.GetSecret(secretname)
+	if err != nil {
+		return 0, err
+	}
 
 	if len(secrets) == 0 {
-		return fmt.Errorf(fmt.Sprintf("no data found in secret: %s\n", secretname))
+		return 0, fmt.Errorf(fmt.Sprintf("no data found in secret: %s\n", secretname))
 	}
+	opts.Tags, err = c.exportTags(kclient, secretname)
+	if err != nil {
+		return 0, err
+	}
+	opts.Description = c.exportDescription(kclient, secretname)
 	if c.encode {
 		encoded, err := c.ssm.EncodeSecrets(secrets)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		secrets = encoded
 	}
-	err = c.ssm.PutSecrets(c.ssmPath, secrets, c.overwrite, c.advanced)
-	if err != nil {
-		return err
+	return len(secrets), c.ssm.PutSecrets(parampath, secrets, opts)
+}
+
+// validateLayout checks that a path layout gives every exported secret a path of its own.
+func validateLayout(layout string, allNamespaces bool) error {
+	if !strings.Contains(layout, layoutSecret) {
+		return fmt.Errorf("error: path layout %q must contain %s", layout, layoutSecret)
+	}
+	if allNamespaces && !strings.Contains(layout, layoutNamespace) {
+		return fmt.Errorf("error: path layout %q must contain %s when exporting from all namespaces", layout, layoutNamespace)
 	}
-	fmt.Printf("exported secret: %s\n", secretname)
 	return nil
 }
+
+// exportPath returns the parameter store path a secret is exported to in a bulk export.
+func exportPath(parampath string, layout string, ref k8s.SecretRef) string {
+	r := strings.NewReplacer(layoutNamespace, ref.Namespace, layoutSecret, ref.Name)
+	return path.Join(parampath, r.Replace(layout))
+}
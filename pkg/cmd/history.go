@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var historyCmd = &cobra.Command{
+	Use:          "history",
+	Short:        "show the version history of each key under an ssm parameter store path",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		return cli.History(c.Context())
+	},
+}
+
+func (c *CommandOptions) History(ctx context.Context) error {
+
+	switch c.historyDiff {
+	case "", valuesHash, valuesPlain:
+	default:
+		return fmt.Errorf("error: --diff must be %s or %s", valuesHash, valuesPlain)
+	}
+
+	found := 0
+	it := c.ssm.NewParameterIterator(ctx, c.ssmPath, c.getOptions())
+	for it.Next() {
+		found++
+		param := it.Parameter()
+		history, err := c.ssm.GetHistory(ctx, param.Name, len(c.historyDiff) > 0)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("ssm:%s\n", param.Name)
+		for i, version := range history {
+			details := []string{
+				fmt.Sprintf("version %d", version.Version),
+				version.LastModifiedDate.UTC().Format(time.RFC3339),
+				fmt.Sprintf("%s tier", version.Tier),
+			}
+			if len(version.LastModifiedUser) > 0 {
+				details = append(details, fmt.Sprintf("by %s", version.LastModifiedUser))
+			}
+			if len(version.Labels) > 0 {
+				details = append(details, fmt.Sprintf("labels: %s", strings.Join(version.Labels, " ")))
+			}
+			fmt.Printf("  %s\n", strings.Join(details, ", "))
+			if len(c.historyDiff) == 0 {
+				continue
+			}
+			switch {
+			case i == 0:
+				fmt.Printf("    value: %s\n", displayValue(version.Value, c.historyDiff))
+			case history[i-1].Value == version.Value:
+				fmt.Printf("    value unchanged\n")
+			default:
+				fmt.Printf("    value changed: %s -> %s\n", displayValue(history[i-1].Value, c.historyDiff), displayValue(version.Value, c.historyDiff))
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if found == 0 {
+		return fmt.Errorf(fmt.Sprintf("no parameters found at path: %s", c.ssmPath))
+	}
+	return nil
+}
@@ -1,35 +1,137 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/spf13/cobra"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/k8s"
 )
 
 var importCmd = &cobra.Command{
 	Use:   "import",
 	Short: "import a kubernetes secret from aws ssm param store",
 	RunE: func(c *cobra.Command, args []string) error {
+		if cli.tree {
+			if len(args) > 0 {
+				return fmt.Errorf("error: a secret name cannot be used with --tree")
+			}
+			return cli.ImportTree(c.Context())
+		}
 		if len(args) < 1 {
 			return fmt.Errorf("error: no secret name provided")
 		}
-		return cli.Import(args)
+		return cli.Import(c.Context(), args)
 	},
 }
 
-func (c *CommandOptions) Import(args []string) error {
+func (c *CommandOptions) Import(ctx context.Context, args []string) error {
+
+	c.SetNamespace()
+	if err := c.validateImport(); err != nil {
+		return err
+	}
+	return c.importSecret(ctx, c.k8s, args[0], c.ssmPath)
+}
+
+// ImportTree imports each child path of the ssm path into a secret named after it. With
+// --tree-namespaces the child paths are nested one level deeper, below a path naming the
+// namespace of the secret. A secret that fails to import is reported and the remaining
+// secrets are still imported.
+func (c *CommandOptions) ImportTree(ctx context.Context) error {
 
 	c.SetNamespace()
-	secretname := args[0]
-	secrets, err := c.ssm.GetSecrets(c.ssmPath)
+	if err := c.validateImport(); err != nil {
+		return err
+	}
+	depth := 1
+	if c.treeNamespaces {
+		depth = 2
+	}
+	children, err := c.ssm.ChildPaths(ctx, c.ssmPath, depth)
 	if err != nil {
 		return err
 	}
+	if len(children) == 0 {
+		return fmt.Errorf(fmt.Sprintf("no child paths found at path: %s", c.ssmPath))
+	}
+	failed := 0
+	for _, child := range children {
+		kclient, secretname := c.k8s, child
+		if c.treeNamespaces {
+			parts := strings.SplitN(child, "/", 2)
+			kclient, secretname = c.k8s.WithNamespace(parts[0]), parts[1]
+		}
+		parampath := path.Join(c.ssmPath, child)
+		if err := c.importSecret(ctx, kclient, secretname, parampath); err != nil {
+			failed++
+			fmt.Printf("failed to import secret: %s/%s: %s\n", kclient.GetNamespace(), secretname, err)
+		}
+	}
+	fmt.Printf("imported %d of %d secrets\n", len(children)-failed, len(children))
+	if failed > 0 {
+		return fmt.Errorf("error: %d secrets failed to import", failed)
+	}
+	return nil
+}
 
-	if len(secrets) == 0 {
-		return fmt.Errorf(fmt.Sprintf("no parameters found at path: %s\n", c.ssmPath))
+// explainImmutable turns the error returned when an immutable secret cannot be changed
+// in place into one that says what to do about it.
+func explainImmutable(secretname string, err error) error {
+	if k8s.IsImmutable(err) {
+		return fmt.Errorf("error: secret %s is immutable, so its data can never be changed in place. Use --replace to delete the secret and create it again with the imported data", secretname)
 	}
+	return err
+}
+
+// validateImport rejects import flags that cannot be used together.
+func (c *CommandOptions) validateImport() error {
+	if c.paramVersion > 0 && len(c.paramLabel) > 0 {
+		return fmt.Errorf("error: --version and --param-label cannot be used together")
+	}
+	if c.serverSide && (c.overwrite || c.merge) {
+		return fmt.Errorf("error: --overwrite and --merge cannot be used with --server-side, which always updates existing secrets and keeps fields owned by other managers")
+	}
+	if c.replace && c.merge {
+		return fmt.Errorf("error: --merge cannot be used with --replace, which recreates the secret with only the imported keys")
+	}
+	if !c.serverSide && c.forceConflicts {
+		return fmt.Errorf("error: --force-conflicts can only be used with --server-side")
+	}
+	return nil
+}
+
+// importSecret reads the parameters under parampath into a secret, creating it or, with
+// --overwrite, updating it when it exists, or with --replace, deleting and recreating it.
+// With --server-side the secret is applied instead.
+func (c *CommandOptions) importSecret(ctx context.Context, kclient *k8s.K8sClient, secretname string, parampath string) error {
+	sopts, err := c.secretOptions()
+	if err != nil {
+		return err
+	}
+	sopts.Merge = c.merge
+	sopts.FieldManager = c.fieldManager
+	sopts.Force = c.forceConflicts
+	sopts.Immutable = c.immutable
+	params, err := c.ssm.GetSecrets(ctx, parampath, c.getOptions())
+	if err != nil {
+		return err
+	}
+
+	if len(params) == 0 {
+		return fmt.Errorf(fmt.Sprintf("no parameters found at path: %s\n", parampath))
+	}
+	for i, param := range params {
+		if i > 0 && params[i-1].Name == param.Name {
+			continue
+		}
+		fmt.Printf("read parameter: %s, version: %d\n", param.Name, param.Version)
+	}
+	secrets := params.Secrets()
 	if c.encode {
 		decoded, err := c.ssm.DecodeSecrets(secrets)
 		if err != nil {
@@ -37,14 +139,44 @@ func (c *CommandOptions) Import(args []string) error {
 		}
 		secrets = decoded
 	}
-	err = c.k8s.CreateSecret(secretname, secrets, c.tls)
+	if err := k8s.ValidateSecret(sopts.Type, secrets); err != nil {
+		return err
+	}
+	sopts.Labels, err = c.importLabels()
+	if err != nil {
+		return err
+	}
+	sopts.Annotations, err = c.importAnnotations(parampath, params)
+	if err != nil {
+		return err
+	}
+	if c.serverSide {
+		err := kclient.ApplySecret(secretname, secrets, sopts)
+		if k8s.IsImmutable(err) && c.replace {
+			if err = kclient.DeleteSecret(secretname); err == nil {
+				err = kclient.ApplySecret(secretname, secrets, sopts)
+			}
+		}
+		if err != nil {
+			return explainImmutable(secretname, err)
+		}
+		fmt.Printf("applied secret: %s, field manager: %s\n", secretname, sopts.FieldManager)
+		return nil
+	}
+	err = kclient.CreateSecret(secretname, secrets, sopts)
 	if err != nil {
 		if kerr.IsAlreadyExists(err) {
-			if c.overwrite {
-				err = c.k8s.UpdateSecret(secretname, secrets)
+			if c.replace {
+				err = kclient.ReplaceSecret(secretname, secrets, sopts)
 				if err != nil {
 					return err
 				}
+				fmt.Printf("replaced secret: %s\n", secretname)
+			} else if c.overwrite {
+				err = kclient.UpdateSecret(secretname, secrets, sopts)
+				if err != nil {
+					return explainImmutable(secretname, err)
+				}
 				fmt.Printf("imported secret: %s\n", secretname)
 			}
 		}
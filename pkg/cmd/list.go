@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -11,13 +13,13 @@ var listCmd = &cobra.Command{
 	Short:        "list ssm parameters by path ",
 	SilenceUsage: true,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return cli.List(args)
+		return cli.List(cmd.Context(), args)
 	},
 }
 
-func (c *CommandOptions) List(args []string) error {
+func (c *CommandOptions) List(ctx context.Context, args []string) error {
 	c.SetNamespace()
-	err := c.ListSsmSecrets()
+	err := c.ListSsmSecrets(ctx)
 	if err != nil {
 		return err
 	}
@@ -28,24 +30,32 @@ func (c *CommandOptions) List(args []string) error {
 	return nil
 }
 
-func (c *CommandOptions) ListSsmSecrets() error {
+func (c *CommandOptions) ListSsmSecrets(ctx context.Context) error {
 	if len(c.ssmPath) > 0 {
-		secrets, err := c.ssm.GetSecrets(c.ssmPath)
-		if err != nil {
+		found := 0
+		it := c.ssm.NewParameterIterator(ctx, c.ssmPath, c.getOptions())
+		for it.Next() {
+			found++
+			param := it.Parameter()
+			if c.toEnvironment {
+				fmt.Printf("%s=%s\n", param.Key, param.Value)
+			} else if c.long {
+				details := fmt.Sprintf("%s, %s tier", param.Type, param.Tier)
+				if len(param.KeyId) > 0 {
+					details = fmt.Sprintf("%s, key %s", details, param.KeyId)
+				}
+				fmt.Printf("ssm:%s/%s: %s (%s, version %d, modified %s)\n", c.ssmPath, param.Key, param.Value,
+					details, param.Version, param.LastModifiedDate.UTC().Format(time.RFC3339))
+			} else {
+				fmt.Printf("ssm:%s/%s: %s\n", c.ssmPath, param.Key, param.Value)
+			}
+		}
+		if err := it.Err(); err != nil {
 			return err
 		}
-		if len(secrets) == 0 {
+		if found == 0 {
 			return fmt.Errorf(fmt.Sprintf("no parameters found at path: %s", c.ssmPath))
 		}
-		if c.toEnvironment {
-			for k, v := range secrets {
-				fmt.Printf("%s=%s\n", k, v)
-			}
-		} else {
-			for k, v := range secrets {
-				fmt.Printf("ssm:%s/%s: %s\n", c.ssmPath, k, v)
-			}
-		}
 	}
 	return nil
 }
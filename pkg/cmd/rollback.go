@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/ssm"
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:          "rollback",
+	Short:        "restore the values of an ssm parameter store path as they were at a point in time",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		return cli.Rollback(c.Context())
+	},
+}
+
+// rollbackLayouts are the timestamp formats accepted by --to, tried in order.
+var rollbackLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+func parseTimestamp(s string) (time.Time, error) {
+	for _, layout := range rollbackLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("error: cannot parse timestamp %q, expected a time such as 2026-10-01T10:00Z", s)
+}
+
+// restore is a parameter whose value as at the rollback time differs from its current value.
+type restore struct {
+	current *ssm.Parameter
+	version *ssm.Parameter
+}
+
+func (c *CommandOptions) Rollback(ctx context.Context) error {
+
+	to, err := parseTimestamp(c.rollbackTo)
+	if err != nil {
+		return err
+	}
+
+	var restores []restore
+	var created []string
+	unchanged := 0
+	fmt.Printf("rollback of %s to %s:\n", c.ssmPath, to.UTC().Format(time.RFC3339))
+	it := c.ssm.NewParameterIterator(ctx, c.ssmPath, c.getOptions())
+	for it.Next() {
+		param := it.Parameter()
+		history, err := c.ssm.GetHistory(ctx, param.Name, true)
+		if err != nil {
+			return err
+		}
+		if len(history) == 0 {
+			continue
+		}
+		current := history[len(history)-1]
+		version := ssm.VersionAt(history, to)
+		switch {
+		case version == nil:
+			created = append(created, param.Name)
+			if c.deleteNew {
+				fmt.Printf("  - %s: did not exist, will be deleted\n", param.Name)
+			} else {
+				fmt.Printf("  ! %s: did not exist, keeping it\n", param.Name)
+			}
+		case version.Value == current.Value:
+			unchanged++
+		default:
+			restores = append(restores, restore{current: current, version: version})
+			fmt.Printf("  ~ %s: version %d will be restored over version %d (%s -> %s)\n", param.Name,
+				version.Version, current.Version, shortHash(current.Value), shortHash(version.Value))
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	fmt.Printf("%d to restore, %d unchanged, %d created since\n", len(restores), unchanged, len(created))
+
+	if !c.deleteNew {
+		created = nil
+	}
+	if len(restores) == 0 && len(created) == 0 {
+		fmt.Printf("nothing to roll back\n")
+		return nil
+	}
+	if c.dryRun {
+		return nil
+	}
+	if !c.yes {
+		ok, err := confirm("roll back these parameters?")
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("rollback cancelled")
+		}
+	}
+
+	for _, r := range restores {
+		tier := r.version.Tier
+		if r.current.Tier == ssm.TierAdvanced {
+			tier = ssm.TierAdvanced
+		}
+		version, err := c.ssm.RestoreVersion(r.version, tier)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("restored parameter: %s, from version: %d, version: %d\n", r.version.Name, r.version.Version, version)
+	}
+	if len(created) == 0 {
+		return nil
+	}
+	all, err := c.ssm.ParameterNames(ctx, c.ssmPath, c.recursive)
+	if err != nil {
+		return err
+	}
+	return c.ssm.DeleteSecrets(ctx, ssm.WithChunks(created, all))
+}
@@ -1,8 +1,15 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -15,6 +22,9 @@ var (
 	# view the parameter store keys and values located in parameter store path /param/path/foo
 	%[1]s list --ssm-path /param/path/foo
 
+	# view the parameter store keys nested below /param/path/foo, flattened to keys like db_user
+	%[1]s list --ssm-path /param/path/foo --recursive
+
 	# view the kubernetes secret called foo
 	%[1]s list foo
 
@@ -24,60 +34,363 @@ var (
 	# export a kubernetes secret called foo to aws ssm parameter store path /param/path/foo
 	%[1]s export foo --ssm-path /param/path/foo
 
+	# show when each key under parameter store path /param/path/foo changed, and who changed it
+	%[1]s history --ssm-path /param/path/foo
+
+	# restore the keys under parameter store path /param/path/foo to their values at a point in time
+	%[1]s rollback --ssm-path /param/path/foo --to 2026-10-01T10:00Z
+
+	# compare the kubernetes secret foo with parameter store path /param/path/foo
+	%[1]s diff foo --ssm-path /param/path/foo
+
+	# write only the changed keys of the kubernetes secret foo to parameter store path /param/path/foo
+	%[1]s sync foo --ssm-path /param/path/foo --direction k8s-to-ssm
+
+	# copy the parameters under /staging/app to /prod/app in another region
+	%[1]s copy --from /staging/app --to /prod/app --to-region us-east-1
+
+	# copy the kubernetes secret foo to namespace bar in the cluster of context prod
+	%[1]s copy-secret foo --to-context prod --to-namespace bar
+
+	# delete the kubernetes secret foo and every parameter under parameter store path /param/path/foo
+	%[1]s delete foo --ssm-path /param/path/foo --recursive
+
+	# export every secret labelled app=foo to its own path below /backup, e.g. /backup/default/foo
+	%[1]s export --selector app=foo --ssm-path /backup
+
+	# import each namespace/secret path below /backup back into a secret in that namespace
+	%[1]s import --tree --tree-namespaces --ssm-path /backup
+
 	# display the plugin version
 	%[1]s version
 `
 	cli *CommandOptions
 )
 
+// provenancePrefix namespaces the tags and annotations this plugin sets automatically.
+const provenancePrefix = "kubectl-ssm-secret/"
+
 type CommandOptions struct {
-	ssmPath       string
-	toSsm         bool
-	args          []string
-	ssm           *ssm.Client
-	k8s           *k8s.K8sClient
-	overwrite     bool
-	advanced      bool
-	encode        bool
-	toEnvironment bool
-	tls           bool
-	namespace     string
+	ssmPath           string
+	toSsm             bool
+	args              []string
+	ssm               *ssm.Client
+	k8s               *k8s.K8sClient
+	overwrite         bool
+	advanced          bool
+	encode            bool
+	toEnvironment     bool
+	tls               bool
+	namespace         string
+	recursive         bool
+	keyScheme         string
+	long              bool
+	kmsKeyId          string
+	typeRules         []string
+	stringLists       string
+	tags              []string
+	labelTags         bool
+	expireAfter       string
+	notifyBefore      string
+	notifyNoChange    string
+	tier              string
+	chunk             bool
+	paramVersion      int64
+	paramLabel        string
+	labels            []string
+	historyDiff       string
+	rollbackTo        string
+	deleteNew         bool
+	dryRun            bool
+	yes               bool
+	showValues        bool
+	direction         string
+	prune             bool
+	copyFrom          string
+	copyTo            string
+	toRegion          string
+	toProfile         string
+	toRoleArn         string
+	keys              []string
+	move              bool
+	fromContext       string
+	toContext         string
+	toNamespace       string
+	rename            string
+	keepLabels        []string
+	keepAnnotations   []string
+	selector          string
+	all               bool
+	allNamespaces     bool
+	pathLayout        string
+	tree              bool
+	treeNamespaces    bool
+	secretType        string
+	merge             bool
+	serverSide        bool
+	forceConflicts    bool
+	fieldManager      string
+	secretLabels      []string
+	secretAnnotations []string
+	immutable         bool
+	replace           bool
+	setupErr          error
 }
 
 // NewCommandOptions provides an instance of CommandOptions with default values
 func NewCommandOptions() *CommandOptions {
-	svc, err := ssm.New()
+	svc, kclient, err := newClients()
+	ns := ""
+	if kclient != nil {
+		ns = kclient.GetNamespace()
+	}
+	return &CommandOptions{
+		toSsm:             false,
+		ssmPath:           "",
+		ssm:               svc,
+		k8s:               kclient,
+		setupErr:          err,
+		overwrite:         false,
+		advanced:          false,
+		encode:            false,
+		toEnvironment:     false,
+		tls:               false,
+		namespace:         ns,
+		recursive:         false,
+		keyScheme:         "underscore",
+		long:              false,
+		kmsKeyId:          "",
+		typeRules:         []string{},
+		stringLists:       ssm.StringListJoin,
+		tags:              []string{},
+		labelTags:         true,
+		expireAfter:       "",
+		notifyBefore:      "",
+		notifyNoChange:    "",
+		tier:              ssm.TierAuto,
+		chunk:             false,
+		paramVersion:      0,
+		paramLabel:        "",
+		labels:            []string{},
+		historyDiff:       "",
+		rollbackTo:        "",
+		deleteNew:         false,
+		dryRun:            false,
+		yes:               false,
+		showValues:        false,
+		direction:         "",
+		prune:             false,
+		copyFrom:          "",
+		copyTo:            "",
+		toRegion:          "",
+		toProfile:         "",
+		toRoleArn:         "",
+		keys:              []string{},
+		move:              false,
+		fromContext:       "",
+		toContext:         "",
+		toNamespace:       "",
+		rename:            "",
+		keepLabels:        []string{},
+		keepAnnotations:   []string{},
+		selector:          "",
+		all:               false,
+		allNamespaces:     false,
+		pathLayout:        "{namespace}/{secret}",
+		tree:              false,
+		treeNamespaces:    false,
+		secretType:        "",
+		merge:             false,
+		serverSide:        false,
+		forceConflicts:    false,
+		fieldManager:      k8s.DefaultFieldManager,
+		secretLabels:      []string{},
+		secretAnnotations: []string{},
+		immutable:         false,
+		replace:           false,
+	}
+}
+
+func (c *CommandOptions) SetNamespace() {
+	c.k8s.SetNamespace(c.namespace)
+}
+
+func (c *CommandOptions) getOptions() ssm.GetOptions {
+	opts := ssm.GetOptions{
+		Recursive:    c.recursive,
+		KeyScheme:    c.keyScheme,
+		WithMetadata: c.long,
+		StringLists:  c.stringLists,
+		Selector:     c.paramLabel,
+	}
+	if c.paramVersion > 0 {
+		opts.Selector = strconv.FormatInt(c.paramVersion, 10)
+	}
+	return opts
+}
+
+func (c *CommandOptions) putOptions() (ssm.PutOptions, error) {
+	tier, err := ssm.ParseTier(c.tier)
 	if err != nil {
-		fmt.Printf("error: cannot create aws ssm client: %s\n", err)
-		os.Exit(1)
+		return ssm.PutOptions{}, err
 	}
-	kconfig, err := k8s.NewK8sConfig()
+	if c.advanced {
+		tier = ssm.TierAdvanced
+	}
+	opts := ssm.PutOptions{
+		Overwrite: c.overwrite,
+		Tier:      tier,
+		Chunk:     c.chunk,
+		KeyId:     c.kmsKeyId,
+		Labels:    c.labels,
+	}
+	for _, r := range c.typeRules {
+		rule, err := ssm.ParseTypeRule(r)
+		if err != nil {
+			return opts, err
+		}
+		opts.TypeRules = append(opts.TypeRules, rule)
+	}
+	policies, err := ssm.BuildPolicies(time.Now(), c.expireAfter, c.notifyBefore, c.notifyNoChange)
 	if err != nil {
-		fmt.Printf("error: cannot configure k8s client: %s\n", err)
-		os.Exit(1)
+		return opts, err
 	}
-	kclient, err := k8s.NewK8sClientFromConfig(kconfig)
+	opts.Policies = policies
+	return opts, nil
+}
+
+// secretOptions returns the options for creating secrets, where --tls is the same as --type tls.
+// The type is left empty when neither is given, so that applying a secret leaves its type alone.
+func (c *CommandOptions) secretOptions() (k8s.SecretOptions, error) {
+	if c.tls {
+		if len(c.secretType) > 0 && c.secretType != "tls" {
+			return k8s.SecretOptions{}, fmt.Errorf("error: --tls cannot be used with --type %s", c.secretType)
+		}
+		return k8s.SecretOptions{Type: k8s.SecretTypes["tls"]}, nil
+	}
+	if len(c.secretType) == 0 {
+		return k8s.SecretOptions{}, nil
+	}
+	stype, err := k8s.ParseSecretType(c.secretType)
 	if err != nil {
-		fmt.Printf("error: cannot init k8s client: %s\n", err)
-		os.Exit(1)
+		return k8s.SecretOptions{}, err
 	}
-	ns := kclient.GetNamespace()
-	return &CommandOptions{
-		toSsm:         false,
-		ssmPath:       "",
-		ssm:           svc,
-		k8s:           kclient,
-		overwrite:     false,
-		advanced:      false,
-		encode:        false,
-		toEnvironment: false,
-		tls:           false,
-		namespace:     ns,
+	return k8s.SecretOptions{Type: stype}, nil
+}
+
+// exportTags builds the tags for parameters exported from a secret. Explicit --tag
+// values take precedence over the automatic provenance tags, which in turn take
+// precedence over the secret's labels.
+func (c *CommandOptions) exportTags(kclient *k8s.K8sClient, secretname string) (map[string]string, error) {
+	tags := make(map[string]string)
+	if c.labelTags {
+		labels, err := kclient.GetSecretLabels(secretname)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range labels {
+			tags[k] = v
+		}
+	}
+	if cluster := kclient.GetCluster(); len(cluster) > 0 {
+		tags[provenancePrefix+"cluster"] = cluster
 	}
+	tags[provenancePrefix+"namespace"] = kclient.GetNamespace()
+	tags[provenancePrefix+"secret"] = secretname
+	tags[provenancePrefix+"version"] = version
+	explicit, err := parseKeyValues(c.tags)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range explicit {
+		tags[k] = v
+	}
+	return tags, nil
 }
 
-func (c *CommandOptions) SetNamespace() {
-	c.k8s.SetNamespace(c.namespace)
+// importLabels builds the labels for an imported secret. Explicit --label values
+// take precedence over the automatic provenance label.
+func (c *CommandOptions) importLabels() (map[string]string, error) {
+	labels := map[string]string{provenancePrefix + "imported": "true"}
+	explicit, err := parseKeyValues(c.secretLabels)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range explicit {
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// importAnnotations builds the annotations for a secret imported from the parameters
+// under parampath, recording where and when it came from. Explicit --annotation
+// values take precedence over the automatic provenance annotations.
+func (c *CommandOptions) importAnnotations(parampath string, params ssm.Parameters) (map[string]string, error) {
+	versions := make(map[string]int64)
+	for _, param := range params {
+		versions[param.Name] = param.Version
+	}
+	b, err := json.Marshal(versions)
+	if err != nil {
+		return nil, err
+	}
+	annotations := map[string]string{
+		provenancePrefix + "source-path": parampath,
+		provenancePrefix + "versions":    string(b),
+		provenancePrefix + "imported-at": time.Now().UTC().Format(time.RFC3339),
+		provenancePrefix + "version":     version,
+	}
+	if region := c.ssm.Region(); len(region) > 0 {
+		annotations[provenancePrefix+"region"] = region
+	}
+	explicit, err := parseKeyValues(c.secretAnnotations)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range explicit {
+		annotations[k] = v
+	}
+	return annotations, nil
+}
+
+// exportDescription describes where exported parameters came from.
+func (c *CommandOptions) exportDescription(kclient *k8s.K8sClient, secretname string) string {
+	description := fmt.Sprintf("exported by kubectl-ssm-secret %s from secret %s/%s", version, kclient.GetNamespace(), secretname)
+	if cluster := kclient.GetCluster(); len(cluster) > 0 {
+		description = fmt.Sprintf("%s in cluster %s", description, cluster)
+	}
+	return description
+}
+
+// parseKeyValues parses a list of key=value pairs into a map.
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	results := make(map[string]string)
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 {
+			return nil, fmt.Errorf("invalid key=value pair: %s", pair)
+		}
+		results[parts[0]] = parts[1]
+	}
+	return results, nil
+}
+
+// newClients creates the aws ssm and k8s clients. A failure is reported when a command
+// runs rather than here, so that each command can exit with its own code.
+func newClients() (*ssm.Client, *k8s.K8sClient, error) {
+	svc, err := ssm.New()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error: cannot create aws ssm client: %s", err)
+	}
+	kconfig, err := k8s.NewK8sConfig()
+	if err != nil {
+		return svc, nil, fmt.Errorf("error: cannot configure k8s client: %s", err)
+	}
+	kclient, err := k8s.NewK8sClientFromConfig(kconfig)
+	if err != nil {
+		return svc, nil, fmt.Errorf("error: cannot init k8s client: %s", err)
+	}
+	return svc, kclient, nil
 }
 
 func init() {
@@ -86,27 +399,124 @@ func init() {
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(importCmd)
 	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(rollbackCmd)
+	rootCmd.AddCommand(deleteCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(copyCmd)
+	rootCmd.AddCommand(copySecretCmd)
 	rootCmd.PersistentFlags().StringVarP(&cli.namespace, "namespace", "n", cli.namespace, "kubernetes namespace")
 	listCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to list parameters from")
 	listCmd.Flags().BoolVarP(&cli.toEnvironment, "env", "e", cli.overwrite, "output as environment variable key pairs")
+	listCmd.Flags().BoolVarP(&cli.long, "long", "l", cli.long, "show the type, tier, kms key, version and last modified date of each parameter")
+	listCmd.Flags().BoolVarP(&cli.recursive, "recursive", "r", cli.recursive, "list parameters nested below the ssm parameter store path")
+	listCmd.Flags().StringVar(&cli.keyScheme, "key-scheme", cli.keyScheme, "how nested parameter names are flattened into keys: underscore (db_user), dot (db.user) or dash (db-user)")
 	importCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to read data from")
 	importCmd.MarkFlagRequired("ssm-path")
 	importCmd.Flags().BoolVarP(&cli.overwrite, "overwrite", "o", cli.overwrite, "if k8s secret exists, overwite its values with those from param store")
 	importCmd.Flags().BoolVarP(&cli.encode, "decode", "d", cli.encode, "treat store values in param store as gzipped, base64 encoded strings")
-	importCmd.Flags().BoolVarP(&cli.tls, "tls", "t", cli.tls, "import ssm param store values to k8s tls secret")
+	importCmd.Flags().BoolVarP(&cli.tls, "tls", "t", cli.tls, "import ssm param store values to k8s tls secret, same as --type tls")
+	importCmd.Flags().StringVar(&cli.secretType, "type", cli.secretType, "type of the k8s secret created: opaque, tls, dockerconfigjson, basic-auth or ssh-auth")
+	importCmd.Flags().BoolVarP(&cli.recursive, "recursive", "r", cli.recursive, "import parameters nested below the ssm parameter store path")
+	importCmd.Flags().StringVar(&cli.keyScheme, "key-scheme", cli.keyScheme, "how nested parameter names are flattened into keys: underscore (db_user), dot (db.user) or dash (db-user)")
+	importCmd.Flags().Int64Var(&cli.paramVersion, "version", cli.paramVersion, "import this version of every parameter instead of the latest")
+	importCmd.Flags().StringVar(&cli.paramLabel, "param-label", cli.paramLabel, "import the version of every parameter carrying this parameter store label instead of the latest")
+	importCmd.Flags().StringVar(&cli.stringLists, "string-list", cli.stringLists, "how StringList parameters are imported: join keeps the comma separated value, split creates an indexed key per item")
 	exportCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to write data to")
 	exportCmd.MarkFlagRequired("ssm-path")
 	exportCmd.Flags().BoolVarP(&cli.overwrite, "overwrite", "o", cli.overwrite, "if parameter store key exists, overwite its values with those from k8s secret")
-	exportCmd.Flags().BoolVarP(&cli.advanced, "advanced", "a", cli.advanced, "if the secret size is over 4 KB but less than 8 KB, export it to an advanced parameter")
+	exportCmd.Flags().BoolVarP(&cli.advanced, "advanced", "a", cli.advanced, "export every key to an advanced parameter, same as --tier advanced")
+	exportCmd.Flags().BoolVar(&cli.chunk, "chunk", cli.chunk, "split values too large for their tier across numbered chunk parameters and a manifest parameter")
+	exportCmd.Flags().StringVar(&cli.tier, "tier", cli.tier, "parameter tier: standard, advanced, intelligent-tiering, or auto to use advanced only for values over 4 KB. auto never picks intelligent-tiering")
 	exportCmd.Flags().BoolVarP(&cli.encode, "encode", "e", cli.encode, "gzip, base64 encode values in parameter store")
+	exportCmd.Flags().StringArrayVar(&cli.typeRules, "type-rule", cli.typeRules, "map keys matching a glob pattern to a parameter type, e.g. '*_HOST=String'. May be repeated, the first matching rule wins and unmatched keys are SecureString")
+	exportCmd.Flags().StringArrayVar(&cli.labels, "label", cli.labels, "attach a parameter store label, such as release-2026-10-18, to every parameter version written. May be repeated")
+	exportCmd.Flags().StringArrayVar(&cli.tags, "tag", cli.tags, "tag exported parameters with a key=value pair. May be repeated")
+	exportCmd.Flags().BoolVar(&cli.labelTags, "label-tags", cli.labelTags, "tag exported parameters with the labels of the kubernetes secret")
+	exportCmd.Flags().StringVar(&cli.expireAfter, "expire-after", cli.expireAfter, "expire exported parameters after a number of days or hours, e.g. 90d. Uses the advanced tier")
+	exportCmd.Flags().StringVar(&cli.notifyBefore, "notify-before", cli.notifyBefore, "send an eventbridge notification this long before exported parameters expire, e.g. 14d. Requires --expire-after")
+	exportCmd.Flags().StringVar(&cli.notifyNoChange, "notify-no-change", cli.notifyNoChange, "send an eventbridge notification when exported parameters have not changed for this long, e.g. 180d. Uses the advanced tier")
+	exportCmd.Flags().StringVarP(&cli.kmsKeyId, "kms-key-id", "k", cli.kmsKeyId, "kms key id, arn or alias used to encrypt SecureString parameters instead of the default aws/ssm key")
+	historyCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to show the history of")
+	historyCmd.MarkFlagRequired("ssm-path")
+	historyCmd.Flags().BoolVarP(&cli.recursive, "recursive", "r", cli.recursive, "include parameters nested below the ssm parameter store path")
+	historyCmd.Flags().StringVar(&cli.historyDiff, "diff", cli.historyDiff, "show how the value changed between consecutive versions, as hash or plain values. Values are not shown by default")
+	rollbackCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to roll back")
+	rollbackCmd.MarkFlagRequired("ssm-path")
+	rollbackCmd.Flags().StringVar(&cli.rollbackTo, "to", cli.rollbackTo, "point in time to roll back to, e.g. 2026-10-01T10:00Z")
+	rollbackCmd.MarkFlagRequired("to")
+	rollbackCmd.Flags().BoolVarP(&cli.recursive, "recursive", "r", cli.recursive, "include parameters nested below the ssm parameter store path")
+	rollbackCmd.Flags().BoolVar(&cli.deleteNew, "delete-new", cli.deleteNew, "delete parameters that did not exist at the point in time")
+	rollbackCmd.Flags().BoolVar(&cli.dryRun, "dry-run", cli.dryRun, "only show what would be rolled back")
+	rollbackCmd.Flags().BoolVarP(&cli.yes, "yes", "y", cli.yes, "roll back without asking for confirmation")
+	deleteCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to delete parameters from")
+	deleteCmd.Flags().BoolVarP(&cli.recursive, "recursive", "r", cli.recursive, "also delete parameters nested below the ssm parameter store path")
+	deleteCmd.Flags().BoolVar(&cli.dryRun, "dry-run", cli.dryRun, "only show what would be deleted")
+	deleteCmd.Flags().BoolVarP(&cli.yes, "yes", "y", cli.yes, "delete without asking for confirmation")
+	diffCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to compare the secret with")
+	diffCmd.Flags().BoolVarP(&cli.encode, "decode", "d", cli.encode, "treat store values in param store as gzipped, base64 encoded strings")
+	diffCmd.Flags().BoolVarP(&cli.recursive, "recursive", "r", cli.recursive, "compare parameters nested below the ssm parameter store path")
+	diffCmd.Flags().StringVar(&cli.keyScheme, "key-scheme", cli.keyScheme, "how nested parameter names are flattened into keys: underscore (db_user), dot (db.user) or dash (db-user)")
+	diffCmd.Flags().BoolVar(&cli.showValues, "show-values", cli.showValues, "show changed values in plain text instead of short hashes")
+	syncCmd.Flags().StringVarP(&cli.ssmPath, "ssm-path", "s", cli.ssmPath, "ssm parameter store path to sync the secret with")
+	syncCmd.MarkFlagRequired("ssm-path")
+	syncCmd.Flags().StringVar(&cli.direction, "direction", cli.direction, "which side is the source: k8s-to-ssm or ssm-to-k8s")
+	syncCmd.MarkFlagRequired("direction")
+	syncCmd.Flags().BoolVar(&cli.prune, "prune", cli.prune, "also remove keys that do not exist on the source side")
+	syncCmd.Flags().BoolVar(&cli.dryRun, "dry-run", cli.dryRun, "only show what would be written and removed")
+	syncCmd.Flags().BoolVarP(&cli.encode, "encode", "e", cli.encode, "treat store values in param store as gzipped, base64 encoded strings")
+	syncCmd.Flags().BoolVarP(&cli.tls, "tls", "t", cli.tls, "create a k8s tls secret when syncing to a secret that does not exist, same as --type tls")
+	syncCmd.Flags().StringVar(&cli.secretType, "type", cli.secretType, "type of the k8s secret created when syncing to a secret that does not exist: opaque, tls, dockerconfigjson, basic-auth or ssh-auth")
+	syncCmd.Flags().StringVar(&cli.tier, "tier", cli.tier, "parameter tier: standard, advanced, intelligent-tiering, or auto to use advanced only for values over 4 KB. auto never picks intelligent-tiering")
+	syncCmd.Flags().BoolVar(&cli.chunk, "chunk", cli.chunk, "split values too large for their tier across numbered chunk parameters and a manifest parameter")
+	syncCmd.Flags().StringArrayVar(&cli.typeRules, "type-rule", cli.typeRules, "map keys matching a glob pattern to a parameter type, e.g. '*_HOST=String'. May be repeated, the first matching rule wins and unmatched keys are SecureString")
+	syncCmd.Flags().StringVarP(&cli.kmsKeyId, "kms-key-id", "k", cli.kmsKeyId, "kms key id, arn or alias used to encrypt SecureString parameters instead of the default aws/ssm key")
+	copyCmd.Flags().StringVar(&cli.copyFrom, "from", cli.copyFrom, "ssm parameter store path to copy parameters from")
+	copyCmd.MarkFlagRequired("from")
+	copyCmd.Flags().StringVar(&cli.copyTo, "to", cli.copyTo, "ssm parameter store path to copy parameters to")
+	copyCmd.MarkFlagRequired("to")
+	copyCmd.Flags().StringVar(&cli.toRegion, "to-region", cli.toRegion, "aws region to copy parameters to, defaults to the source region")
+	copyCmd.Flags().StringVar(&cli.toProfile, "to-profile", cli.toProfile, "aws shared config profile used to write parameters at the destination")
+	copyCmd.Flags().StringVar(&cli.toRoleArn, "to-role-arn", cli.toRoleArn, "iam role assumed, with the --to-profile credentials if given, to write parameters at the destination")
+	copyCmd.Flags().StringVarP(&cli.kmsKeyId, "kms-key-id", "k", cli.kmsKeyId, "kms key id, arn or alias used to encrypt SecureString parameters at the destination")
+	copyCmd.Flags().StringSliceVar(&cli.keys, "keys", cli.keys, "only copy these keys, comma separated or repeated")
+	copyCmd.Flags().BoolVar(&cli.move, "move", cli.move, "delete the source parameters once they have all been copied")
+	copyCmd.Flags().BoolVarP(&cli.overwrite, "overwrite", "o", cli.overwrite, "overwrite parameters that already exist at the destination")
+	copyCmd.Flags().BoolVarP(&cli.recursive, "recursive", "r", cli.recursive, "copy parameters nested below the ssm parameter store path")
+	copyCmd.Flags().StringVar(&cli.keyScheme, "key-scheme", cli.keyScheme, "how nested parameter names are flattened into keys: underscore (db_user), dot (db.user) or dash (db-user)")
+	copySecretCmd.Flags().StringVar(&cli.fromContext, "from-context", cli.fromContext, "kubeconfig context to copy the secret from, defaults to the current context")
+	copySecretCmd.Flags().StringVar(&cli.toContext, "to-context", cli.toContext, "kubeconfig context to copy the secret to, defaults to the source context")
+	copySecretCmd.Flags().StringVar(&cli.toNamespace, "to-namespace", cli.toNamespace, "namespace to copy the secret to, defaults to the source namespace, or that of --to-context")
+	copySecretCmd.Flags().StringVar(&cli.rename, "rename", cli.rename, "name of the copied secret, defaults to the source secret name")
+	copySecretCmd.Flags().StringSliceVar(&cli.keepLabels, "keep-label", cli.keepLabels, "label keys to copy with the secret, comma separated or repeated, or * for all")
+	copySecretCmd.Flags().StringSliceVar(&cli.keepAnnotations, "keep-annotation", cli.keepAnnotations, "annotation keys to copy with the secret, comma separated or repeated, or * for all")
+	copySecretCmd.Flags().BoolVarP(&cli.overwrite, "overwrite", "o", cli.overwrite, "if the destination secret exists, overwrite its values with those of the source secret")
+	exportCmd.Flags().StringVarP(&cli.selector, "selector", "l", cli.selector, "export every secret matching this label selector instead of a named secret, e.g. app=foo")
+	exportCmd.Flags().BoolVar(&cli.all, "all", cli.all, "export every secret in the namespace instead of a named secret")
+	exportCmd.Flags().BoolVarP(&cli.allNamespaces, "all-namespaces", "A", cli.allNamespaces, "export matching secrets from every namespace")
+	exportCmd.Flags().StringVar(&cli.pathLayout, "path-layout", cli.pathLayout, "path below the ssm parameter store path each secret is exported to by --selector or --all, using {namespace} and {secret}")
+	importCmd.Flags().BoolVar(&cli.merge, "merge", cli.merge, "with --overwrite, keep keys of the existing k8s secret that are not in param store instead of removing them")
+	importCmd.Flags().BoolVar(&cli.serverSide, "server-side", cli.serverSide, "create or update the k8s secret with server-side apply, leaving fields owned by other managers alone")
+	importCmd.Flags().BoolVar(&cli.forceConflicts, "force-conflicts", cli.forceConflicts, "with --server-side, take ownership of fields other managers own instead of failing")
+	importCmd.Flags().StringVar(&cli.fieldManager, "field-manager", cli.fieldManager, "field manager name used with --server-side")
+	importCmd.Flags().StringArrayVar(&cli.secretLabels, "label", cli.secretLabels, "label to set on the k8s secret as key=value, may be repeated")
+	importCmd.Flags().StringArrayVar(&cli.secretAnnotations, "annotation", cli.secretAnnotations, "annotation to set on the k8s secret as key=value, may be repeated")
+	importCmd.Flags().BoolVar(&cli.immutable, "immutable", cli.immutable, "mark the k8s secret immutable, so its data can never be changed in place")
+	importCmd.Flags().BoolVar(&cli.replace, "replace", cli.replace, "if k8s secret exists, delete it and create it again with the values from param store, as needed to change an immutable secret")
+	importCmd.Flags().BoolVar(&cli.tree, "tree", cli.tree, "import each child path of the ssm parameter store path into a secret named after it")
+	importCmd.Flags().BoolVar(&cli.treeNamespaces, "tree-namespaces", cli.treeNamespaces, "with --tree, child paths are nested below a path naming the namespace of each secret, as written by export --all-namespaces")
 }
 
 var rootCmd = &cobra.Command{
-	Use:              "ssm-secret list|import|export secret [flags]",
+	Use:              "ssm-secret list|import|export|history|rollback|delete|diff|sync|copy|copy-secret secret [flags]",
 	Short:            "view or import/export k8s secrets from/to aws ssm param store",
 	Example:          fmt.Sprintf(commandExample, "kubectl ssm-secret"),
 	SilenceUsage:     true,
 	TraverseChildren: true,
+	PersistentPreRunE: func(c *cobra.Command, args []string) error {
+		return cli.setupErr
+	},
 	RunE: func(c *cobra.Command, args []string) error {
 		if len(args) < 1 {
 			return fmt.Errorf("no sub command provided")
@@ -117,7 +527,28 @@ var rootCmd = &cobra.Command{
 
 // Execute is used to run the command logic in a vein similar to the Cobra package
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		stop()
+		var exit *exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		os.Exit(1)
 	}
 }
+
+// exitError is returned by commands that exit with a code other than 1 on failure.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
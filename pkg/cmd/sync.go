@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/pr8kerl/kubectl-ssm-secret/pkg/ssm"
+)
+
+// sync directions
+const (
+	syncToSsm = "k8s-to-ssm"
+	syncToK8s = "ssm-to-k8s"
+)
+
+var syncCmd = &cobra.Command{
+	Use:          "sync",
+	Short:        "write only the keys that differ between a kubernetes secret and an ssm parameter store path",
+	SilenceUsage: true,
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return fmt.Errorf("error: no secret name provided")
+		}
+		return cli.Sync(c.Context(), args)
+	},
+}
+
+func (c *CommandOptions) Sync(ctx context.Context, args []string) error {
+
+	c.SetNamespace()
+	if c.direction != syncToSsm && c.direction != syncToK8s {
+		return fmt.Errorf("error: unknown direction %q, must be one of %s or %s", c.direction, syncToSsm, syncToK8s)
+	}
+	sopts, err := c.secretOptions()
+	if err != nil {
+		return err
+	}
+	secretname := args[0]
+	exists := true
+	secrets, err := c.k8s.GetSecret(secretname)
+	if err != nil {
+		if !kerr.IsNotFound(err) || c.direction != syncToK8s {
+			return err
+		}
+		exists = false
+	}
+	params, values, err := c.ssmSecrets(ctx)
+	if err != nil {
+		return err
+	}
+
+	source, target := secrets, values
+	from, to := fmt.Sprintf("k8s:%s/%s", c.namespace, secretname), fmt.Sprintf("ssm:%s", c.ssmPath)
+	if c.direction == syncToK8s {
+		source, target = values, secrets
+		from, to = to, from
+	}
+	if len(source) == 0 {
+		return fmt.Errorf("error: nothing to sync, %s is empty", from)
+	}
+
+	d := compareSecrets(target, source)
+	writes := make(map[string]string)
+	fmt.Printf("sync of %s to %s:\n", from, to)
+	for _, k := range d.added {
+		writes[k] = source[k]
+		fmt.Printf("  + %s: %s\n", k, shortHash(source[k]))
+	}
+	for _, k := range d.changed {
+		writes[k] = source[k]
+		fmt.Printf("  ~ %s: %s -> %s\n", k, shortHash(target[k]), shortHash(source[k]))
+	}
+	var prune []string
+	for _, k := range d.removed {
+		if c.prune {
+			prune = append(prune, k)
+			fmt.Printf("  - %s: %s\n", k, shortHash(target[k]))
+		} else {
+			fmt.Printf("  ! %s: not in %s, keeping it\n", k, from)
+		}
+	}
+	if len(writes) == 0 && len(prune) == 0 {
+		fmt.Printf("%s is in sync with %s\n", to, from)
+		return nil
+	}
+	fmt.Printf("%d to write, %d to remove\n", len(writes), len(prune))
+	if c.dryRun {
+		return nil
+	}
+
+	if c.direction == syncToSsm {
+		err = c.syncToSsm(ctx, secretname, writes, prune, params)
+	} else if !exists {
+		err = c.k8s.CreateSecret(secretname, writes, sopts)
+	} else {
+		err = c.k8s.SetSecretKeys(secretname, writes, prune)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("synced secret: %s, direction: %s\n", secretname, c.direction)
+	return nil
+}
+
+// syncToSsm writes the changed keys of a secret to parameter store and deletes the
+// parameters, chunks included, of the keys being pruned.
+func (c *CommandOptions) syncToSsm(ctx context.Context, secretname string, writes map[string]string, prune []string, params ssm.Parameters) error {
+	if len(writes) > 0 {
+		opts, err := c.putOptions()
+		if err != nil {
+			return err
+		}
+		opts.Overwrite = true
+		opts.Tags, err = c.exportTags(c.k8s, secretname)
+		if err != nil {
+			return err
+		}
+		opts.Description = c.exportDescription(c.k8s, secretname)
+		if c.encode {
+			writes, err = c.ssm.EncodeSecrets(writes)
+			if err != nil {
+				return err
+			}
+		}
+		if err := c.ssm.PutSecrets(c.ssmPath, writes, opts); err != nil {
+			return err
+		}
+	}
+	if len(prune) == 0 {
+		return nil
+	}
+	pruned := make(map[string]bool)
+	for _, k := range prune {
+		pruned[k] = true
+	}
+	var names []string
+	for _, param := range params {
+		if pruned[param.Key] {
+			names = append(names, param.Name)
+		}
+	}
+	all, err := c.ssm.ParameterNames(ctx, c.ssmPath, false)
+	if err != nil {
+		return err
+	}
+	return c.ssm.DeleteSecrets(ctx, ssm.WithChunks(names, all))
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// value display modes, used wherever secret values may be printed
+const (
+	valuesHash  = "hash"
+	valuesPlain = "plain"
+)
+
+// shortHash returns a short fingerprint of a value that is safe to print.
+func shortHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// displayValue renders a secret value for output in the given mode.
+func displayValue(value string, mode string) string {
+	switch mode {
+	case valuesPlain:
+		return fmt.Sprintf("%q", value)
+	case valuesHash:
+		return shortHash(value)
+	}
+	return "****"
+}
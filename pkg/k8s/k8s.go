@@ -3,22 +3,37 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	v1 "k8s.io/api/core/v1"
+	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
 )
 
 type K8sClient struct {
 	client    kubernetes.Interface
 	namespace string
+	cluster   string
+}
+
+// CopyOptions selects the labels and annotations kept when a secret is copied, by key,
+// where "*" keeps them all. Overwrite replaces the data of an existing secret of the same
+// name at the destination.
+type CopyOptions struct {
+	Labels      []string
+	Annotations []string
+	Overwrite   bool
 }
 
 type K8sConfig struct {
 	rest      *rest.Config
 	namespace string
+	cluster   string
 }
 
 func NewK8sClient(client kubernetes.Interface, ns string) *K8sClient {
@@ -34,12 +49,20 @@ func NewK8sClientFromConfig(config *K8sConfig) (*K8sClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewK8sClient(clientset, config.namespace), nil
+	k := NewK8sClient(clientset, config.namespace)
+	k.cluster = config.cluster
+	return k, nil
 }
 
 func NewK8sConfig() (*K8sConfig, error) {
+	return NewK8sConfigForContext("")
+}
+
+// NewK8sConfigForContext loads the kubeconfig for the named context, or for the current
+// context when the name is empty.
+func NewK8sConfigForContext(kubecontext string) (*K8sConfig, error) {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-	configOverrides := &clientcmd.ConfigOverrides{}
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: kubecontext}
 
 	kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 	config, err := kubeConfig.ClientConfig()
@@ -50,10 +73,22 @@ func NewK8sConfig() (*K8sConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	raw, err := kubeConfig.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	if len(kubecontext) == 0 {
+		kubecontext = raw.CurrentContext
+	}
+	var cluster string
+	if kctx, ok := raw.Contexts[kubecontext]; ok {
+		cluster = kctx.Cluster
+	}
 
 	return &K8sConfig{
 		rest:      config,
 		namespace: ns,
+		cluster:   cluster,
 	}, nil
 }
 
@@ -63,27 +98,50 @@ func (c *K8sClient) SetNamespace(ns string) {
 	}
 }
 
+// WithNamespace returns a copy of the client using the given namespace.
+func (c *K8sClient) WithNamespace(ns string) *K8sClient {
+	k := *c
+	k.SetNamespace(ns)
+	return &k
+}
+
 func (c *K8sClient) GetNamespace() string {
 	return c.namespace
 }
 
-func (c *K8sClient) CreateSecret(secretname string, secrets map[string]string, tls bool) error {
+// GetCluster returns the name of the cluster in the current kubeconfig context.
+func (c *K8sClient) GetCluster() string {
+	return c.cluster
+}
+
+// CreateSecret creates a secret of the type and with the labels and annotations given by
+// opts, after checking that it holds the keys its type requires.
+func (c *K8sClient) CreateSecret(secretname string, secrets map[string]string, opts SecretOptions) error {
 
 	if len(secrets) == 0 {
 		return fmt.Errorf(fmt.Sprintf("k8s.CreateSecret: no secrets provided."))
 	}
-	var stype v1.SecretType = "Opaque"
-	if tls {
-		stype = "kubernetes.io/tls"
+	stype := opts.Type
+	if len(stype) == 0 {
+		stype = v1.SecretTypeOpaque
+	}
+	if err := ValidateSecret(stype, secrets); err != nil {
+		return err
+	}
+	if err := ValidateMetadata(opts.Labels, opts.Annotations); err != nil {
+		return err
 	}
 	_, err := c.client.CoreV1().Secrets(c.namespace).Create(
 		context.Background(),
 		&v1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: secretname,
+				Name:        secretname,
+				Labels:      opts.Labels,
+				Annotations: opts.Annotations,
 			},
-			Type: stype,
-			Data: secretStringToBytes(secrets),
+			Type:      stype,
+			Data:      secretStringToBytes(secrets),
+			Immutable: immutable(opts.Immutable),
 		},
 		metav1.CreateOptions{},
 	)
@@ -93,25 +151,193 @@ func (c *K8sClient) CreateSecret(secretname string, secrets map[string]string, t
 	return nil
 }
 
-func (c *K8sClient) UpdateSecret(secretname string, secrets map[string]string) error {
+// UpdateSecret replaces the data of an existing secret, keeping its type and metadata.
+// With opts.Merge, keys of the secret that are not in secrets are kept too. The labels and
+// annotations in opts are added to those the secret has. Immutable secrets are refused
+// with an ImmutableError, while a mutable secret can be made immutable by opts.Immutable.
+func (c *K8sClient) UpdateSecret(secretname string, secrets map[string]string, opts SecretOptions) error {
 
 	if len(secrets) == 0 {
 		return fmt.Errorf(fmt.Sprintf("k8s.UpdateSecret: no secrets provided."))
 	}
-	_, err := c.client.CoreV1().Secrets(c.namespace).Update(
+	if err := ValidateMetadata(opts.Labels, opts.Annotations); err != nil {
+		return err
+	}
+	return c.modifySecret(secretname, func(secret *v1.Secret) error {
+		if secret.Immutable != nil && *secret.Immutable {
+			return &ImmutableError{Name: secretname}
+		}
+		if !opts.Merge || secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		for k, v := range secrets {
+			secret.Data[k] = []byte(v)
+		}
+		if len(opts.Labels) > 0 {
+			secret.Labels = mergeKeys(secret.Labels, opts.Labels)
+		}
+		if len(opts.Annotations) > 0 {
+			secret.Annotations = mergeKeys(secret.Annotations, opts.Annotations)
+		}
+		if opts.Immutable {
+			secret.Immutable = immutable(true)
+		}
+		return ValidateSecret(secret.Type, secretDataToString(secret))
+	})
+}
+
+// ReplaceSecret deletes a secret and creates it again with new data, which is the only
+// way to change an immutable secret. The type, labels, annotations and owner references
+// of the old secret are kept unless opts gives new ones, and an immutable secret stays
+// immutable.
+func (c *K8sClient) ReplaceSecret(secretname string, secrets map[string]string, opts SecretOptions) error {
+
+	if len(secrets) == 0 {
+		return fmt.Errorf(fmt.Sprintf("k8s.ReplaceSecret: no secrets provided."))
+	}
+	old, err := c.client.CoreV1().Secrets(c.namespace).Get(
 		context.Background(),
-		&v1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: secretname,
-			},
-			StringData: secrets,
-		},
-		metav1.UpdateOptions{},
+		secretname,
+		metav1.GetOptions{},
 	)
 	if err != nil {
 		return err
 	}
-	return nil
+	stype := opts.Type
+	if len(stype) == 0 {
+		stype = old.Type
+	}
+	if err := ValidateSecret(stype, secrets); err != nil {
+		return err
+	}
+	if err := ValidateMetadata(opts.Labels, opts.Annotations); err != nil {
+		return err
+	}
+	err = c.client.CoreV1().Secrets(c.namespace).Delete(
+		context.Background(),
+		secretname,
+		metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &old.UID}},
+	)
+	if err != nil && !kerr.IsNotFound(err) {
+		return err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            secretname,
+			Labels:          mergeKeys(old.Labels, opts.Labels),
+			Annotations:     mergeKeys(old.Annotations, opts.Annotations),
+			OwnerReferences: old.OwnerReferences,
+		},
+		Type:      stype,
+		Data:      secretStringToBytes(secrets),
+		Immutable: immutable(opts.Immutable || (old.Immutable != nil && *old.Immutable)),
+	}
+	// the old secret may take a moment to go once deleted
+	return retry.OnError(retry.DefaultBackoff, kerr.IsAlreadyExists, func() error {
+		_, err := c.client.CoreV1().Secrets(c.namespace).Create(
+			context.Background(),
+			secret,
+			metav1.CreateOptions{},
+		)
+		return err
+	})
+}
+
+// ApplySecret creates or updates a secret with server-side apply, as opts.FieldManager,
+// so that fields of the secret owned by other managers, such as gitops tools, are left
+// alone. The type is only applied when opts.Type is set. Conflicts with other managers
+// over the fields applied fail the apply unless opts.Force is set, and an existing
+// immutable secret is never applied to.
+func (c *K8sClient) ApplySecret(secretname string, secrets map[string]string, opts SecretOptions) error {
+
+	if len(secrets) == 0 {
+		return fmt.Errorf(fmt.Sprintf("k8s.ApplySecret: no secrets provided."))
+	}
+	secret := corev1ac.Secret(secretname, c.namespace).WithData(secretStringToBytes(secrets))
+	if len(opts.Type) > 0 {
+		if err := ValidateSecret(opts.Type, secrets); err != nil {
+			return err
+		}
+		secret.WithType(opts.Type)
+	}
+	if err := ValidateMetadata(opts.Labels, opts.Annotations); err != nil {
+		return err
+	}
+	if len(opts.Labels) > 0 {
+		secret.WithLabels(opts.Labels)
+	}
+	if len(opts.Annotations) > 0 {
+		secret.WithAnnotations(opts.Annotations)
+	}
+	if opts.Immutable {
+		secret.WithImmutable(true)
+	}
+	existing, err := c.client.CoreV1().Secrets(c.namespace).Get(
+		context.Background(),
+		secretname,
+		metav1.GetOptions{},
+	)
+	if err != nil && !kerr.IsNotFound(err) {
+		return err
+	}
+	if err == nil && existing.Immutable != nil && *existing.Immutable {
+		return &ImmutableError{Name: secretname}
+	}
+	fieldManager := opts.FieldManager
+	if len(fieldManager) == 0 {
+		fieldManager = DefaultFieldManager
+	}
+	_, err = c.client.CoreV1().Secrets(c.namespace).Apply(
+		context.Background(),
+		secret,
+		metav1.ApplyOptions{FieldManager: fieldManager, Force: opts.Force},
+	)
+	return err
+}
+
+// SetSecretKeys sets and removes keys in an existing secret, leaving every other key
+// and the secret's metadata as they are.
+func (c *K8sClient) SetSecretKeys(secretname string, set map[string]string, remove []string) error {
+
+	return c.modifySecret(secretname, func(secret *v1.Secret) error {
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		for k, v := range set {
+			secret.Data[k] = []byte(v)
+		}
+		for _, k := range remove {
+			delete(secret.Data, k)
+		}
+		return nil
+	})
+}
+
+// modifySecret reads a secret, applies modify to it and writes it back. The write is
+// made against the resource version read, and the whole read, modify and write is
+// retried when the secret was changed by someone else in the meantime.
+func (c *K8sClient) modifySecret(secretname string, modify func(secret *v1.Secret) error) error {
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := c.client.CoreV1().Secrets(c.namespace).Get(
+			context.Background(),
+			secretname,
+			metav1.GetOptions{},
+		)
+		if err != nil {
+			return err
+		}
+		secret.StringData = nil
+		if err := modify(secret); err != nil {
+			return err
+		}
+		_, err = c.client.CoreV1().Secrets(c.namespace).Update(
+			context.Background(),
+			secret,
+			metav1.UpdateOptions{},
+		)
+		return err
+	})
 }
 
 func (c *K8sClient) GetSecret(secretname string) (map[string]string, error) {
@@ -127,6 +353,151 @@ func (c *K8sClient) GetSecret(secretname string) (map[string]string, error) {
 	return secretDataToString(secret), nil
 }
 
+// DeleteSecret deletes a secret.
+func (c *K8sClient) DeleteSecret(secretname string) error {
+
+	return c.client.CoreV1().Secrets(c.namespace).Delete(
+		context.Background(),
+		secretname,
+		metav1.DeleteOptions{},
+	)
+}
+
+// CopySecret copies a secret to the namespace of dest under destname, keeping its type,
+// data, immutability and the labels and annotations selected by opts. Metadata set by the server, such
+// as the uid, resource version and managed fields, is not copied.
+func (c *K8sClient) CopySecret(secretname string, dest *K8sClient, destname string, opts CopyOptions) error {
+
+	secret, err := c.client.CoreV1().Secrets(c.namespace).Get(
+		context.Background(),
+		secretname,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return err
+	}
+	copied := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        destname,
+			Namespace:   dest.namespace,
+			Labels:      selectKeys(secret.Labels, opts.Labels),
+			Annotations: selectKeys(secret.Annotations, opts.Annotations),
+		},
+		Type:      secret.Type,
+		Data:      secret.Data,
+		Immutable: secret.Immutable,
+	}
+	_, err = dest.client.CoreV1().Secrets(dest.namespace).Create(
+		context.Background(),
+		copied,
+		metav1.CreateOptions{},
+	)
+	if !kerr.IsAlreadyExists(err) || !opts.Overwrite {
+		return err
+	}
+	return dest.modifySecret(destname, func(existing *v1.Secret) error {
+		existing.Data = copied.Data
+		existing.Labels = mergeKeys(existing.Labels, copied.Labels)
+		existing.Annotations = mergeKeys(existing.Annotations, copied.Annotations)
+		return nil
+	})
+}
+
+// SecretRef names a secret in a namespace.
+type SecretRef struct {
+	Namespace string
+	Name      string
+}
+
+// ListSecrets returns the secrets matching a label selector, which may be empty to
+// match every secret, in the client's namespace or in every namespace. Service account
+// token secrets are left out, since kubernetes manages them itself.
+func (c *K8sClient) ListSecrets(selector string, allNamespaces bool) ([]SecretRef, error) {
+
+	ns := c.namespace
+	if allNamespaces {
+		ns = metav1.NamespaceAll
+	}
+	var results []SecretRef
+	opts := metav1.ListOptions{LabelSelector: selector}
+	for {
+		list, err := c.client.CoreV1().Secrets(ns).List(context.Background(), opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, secret := range list.Items {
+			if secret.Type == v1.SecretTypeServiceAccountToken {
+				continue
+			}
+			results = append(results, SecretRef{Namespace: secret.Namespace, Name: secret.Name})
+		}
+		if len(list.Continue) == 0 {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Namespace != results[j].Namespace {
+			return results[i].Namespace < results[j].Namespace
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results, nil
+}
+
+// GetSecretLabels returns the labels set on a secret.
+func (c *K8sClient) GetSecretLabels(secretname string) (map[string]string, error) {
+
+	secret, err := c.client.CoreV1().Secrets(c.namespace).Get(
+		context.Background(),
+		secretname,
+		metav1.GetOptions{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return secret.Labels, nil
+}
+
+// immutable returns the value of the immutable field of a secret, which is left unset
+// rather than false for mutable secrets.
+func immutable(set bool) *bool {
+	if !set {
+		return nil
+	}
+	return &set
+}
+
+// selectKeys returns the entries of m whose keys are listed in keys, or every entry
+// when keys holds "*".
+func selectKeys(m map[string]string, keys []string) map[string]string {
+	results := make(map[string]string)
+	for _, k := range keys {
+		if k == "*" {
+			for k, v := range m {
+				results[k] = v
+			}
+			return results
+		}
+		if v, ok := m[k]; ok {
+			results[k] = v
+		}
+	}
+	return results
+}
+
+// mergeKeys returns the entries of m overridden by those of overrides.
+func mergeKeys(m map[string]string, overrides map[string]string) map[string]string {
+	results := make(map[string]string)
+	for k, v := range m {
+		results[k] = v
+	}
+	for k, v := range overrides {
+		results[k] = v
+	}
+	return results
+}
+
 func secretDataToString(secret *v1.Secret) map[string]string {
 	results := make(map[string]string)
 	for k, v := range secret.Data {
@@ -1,13 +1,19 @@
 package k8s
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	kerr "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestK8sCreateSecret(t *testing.T) {
@@ -18,17 +24,86 @@ func TestK8sCreateSecret(t *testing.T) {
 		namespace: "test",
 	}
 	t.Run("test CreateSecret returns expected results", func(t *testing.T) {
-		err := k.CreateSecret("test", mockSecretData(), false)
+		err := k.CreateSecret("test", mockSecretData(), SecretOptions{})
 		assert.Nil(t, err)
 	})
 	t.Run("test CreateSecret fails with alreadyExists", func(t *testing.T) {
-		err := k.CreateSecret("test", mockSecretData(), false)
+		err := k.CreateSecret("test", mockSecretData(), SecretOptions{})
 		assert.NotNil(t, err)
 		assert.True(t, kerr.IsAlreadyExists(err))
 	})
 
 }
 
+func TestK8sCreateTypedSecret(t *testing.T) {
+
+	fakeClient := fake.NewSimpleClientset()
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test CreateSecret creates a secret of the given type", func(t *testing.T) {
+		err := k.CreateSecret("tls", map[string]string{"tls.crt": "cert", "tls.key": "key"}, SecretOptions{Type: v1.SecretTypeTLS})
+		assert.Nil(t, err)
+		secret, err := fakeClient.CoreV1().Secrets("test").Get(context.Background(), "tls", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, v1.SecretTypeTLS, secret.Type)
+	})
+	t.Run("test CreateSecret sets labels and annotations", func(t *testing.T) {
+		err := k.CreateSecret("labelled", mockSecretData(), SecretOptions{
+			Labels:      map[string]string{"app": "squirrel"},
+			Annotations: map[string]string{"kubectl-ssm-secret/source-path": "/foo"},
+		})
+		assert.Nil(t, err)
+		secret, err := fakeClient.CoreV1().Secrets("test").Get(context.Background(), "labelled", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"app": "squirrel"}, secret.Labels)
+		assert.Equal(t, "/foo", secret.Annotations["kubectl-ssm-secret/source-path"])
+	})
+	t.Run("test CreateSecret fails with invalid labels", func(t *testing.T) {
+		err := k.CreateSecret("invalid", mockSecretData(), SecretOptions{Labels: map[string]string{"app": "not valid!"}})
+		assert.NotNil(t, err)
+		err = k.CreateSecret("invalid", mockSecretData(), SecretOptions{Annotations: map[string]string{"/bad": "x"}})
+		assert.NotNil(t, err)
+	})
+	t.Run("test CreateSecret fails when required keys are missing", func(t *testing.T) {
+		err := k.CreateSecret("broken", map[string]string{"tls.crt": "cert"}, SecretOptions{Type: v1.SecretTypeTLS})
+		assert.EqualError(t, err, "k8s: secret of type kubernetes.io/tls is missing required keys: tls.key")
+		_, err = fakeClient.CoreV1().Secrets("test").Get(context.Background(), "broken", metav1.GetOptions{})
+		assert.True(t, kerr.IsNotFound(err))
+	})
+
+}
+
+func TestK8sSecretTypes(t *testing.T) {
+
+	t.Run("test ParseSecretType accepts short and full type names", func(t *testing.T) {
+		for name, wanted := range map[string]v1.SecretType{
+			"":                               v1.SecretTypeOpaque,
+			"tls":                            v1.SecretTypeTLS,
+			"basic-auth":                     v1.SecretTypeBasicAuth,
+			"kubernetes.io/dockerconfigjson": v1.SecretTypeDockerConfigJson,
+		} {
+			stype, err := ParseSecretType(name)
+			assert.Nil(t, err)
+			assert.Equal(t, wanted, stype)
+		}
+		_, err := ParseSecretType("squirrel")
+		assert.NotNil(t, err)
+	})
+	t.Run("test ValidateSecret checks the keys each type requires", func(t *testing.T) {
+		assert.Nil(t, ValidateSecret(v1.SecretTypeOpaque, map[string]string{"foo": "bar"}))
+		assert.Nil(t, ValidateSecret(v1.SecretTypeBasicAuth, map[string]string{"password": "nuts"}))
+		assert.NotNil(t, ValidateSecret(v1.SecretTypeBasicAuth, map[string]string{"foo": "bar"}))
+		assert.Nil(t, ValidateSecret(v1.SecretTypeSSHAuth, map[string]string{"ssh-privatekey": "key"}))
+		assert.NotNil(t, ValidateSecret(v1.SecretTypeSSHAuth, map[string]string{"foo": "bar"}))
+		assert.Nil(t, ValidateSecret(v1.SecretTypeDockerConfigJson, map[string]string{".dockerconfigjson": `{"auths":{}}`}))
+		assert.NotNil(t, ValidateSecret(v1.SecretTypeDockerConfigJson, map[string]string{".dockerconfigjson": "squirrel"}))
+		assert.NotNil(t, ValidateSecret(v1.SecretTypeDockerConfigJson, map[string]string{}))
+	})
+
+}
+
 func TestK8sUpdateSecret(t *testing.T) {
 
 	fakeClient := fake.NewSimpleClientset()
@@ -37,19 +112,77 @@ func TestK8sUpdateSecret(t *testing.T) {
 		namespace: "test",
 	}
 	t.Run("test UpdateSecret fails when secrets not exists", func(t *testing.T) {
-		err := k.UpdateSecret("test", mockSecretData())
+		err := k.UpdateSecret("test", mockSecretData(), SecretOptions{})
 		assert.NotNil(t, err)
 		assert.True(t, kerr.IsNotFound(err))
 	})
 	t.Run("test UpdateSecret succeeds", func(t *testing.T) {
-		err := k.CreateSecret("test", mockSecretData(), false)
+		err := k.CreateSecret("test", mockSecretData(), SecretOptions{})
 		assert.Nil(t, err)
-		err = k.UpdateSecret("test", mockSecretData())
+		err = k.UpdateSecret("test", mockSecretData(), SecretOptions{})
 		assert.Nil(t, err)
 	})
 
 }
 
+func TestK8sUpdateSecretKeepsMetadata(t *testing.T) {
+
+	secret := mockSecret(nil)
+	secret.Data = secretStringToBytes(map[string]string{"tls.crt": "cert", "tls.key": "key", "stale": "nuts"})
+	secret.Type = v1.SecretTypeTLS
+	secret.Labels = map[string]string{"app": "squirrel"}
+	secret.Annotations = map[string]string{"owner": "gerald"}
+	secret.OwnerReferences = []metav1.OwnerReference{{Kind: "Certificate", Name: "squirrel"}}
+	fakeClient := fake.NewSimpleClientset(secret)
+	conflicts := 0
+	fakeClient.PrependReactor("update", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if conflicts == 0 {
+			conflicts++
+			return true, nil, kerr.NewConflict(v1.Resource("secrets"), "test", fmt.Errorf("the object has been modified"))
+		}
+		return false, nil, nil
+	})
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test UpdateSecret replaces data, keeps metadata and retries on conflict", func(t *testing.T) {
+		err := k.UpdateSecret("test", map[string]string{"tls.crt": "new-cert", "tls.key": "new-key"}, SecretOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, conflicts)
+		updated, err := fakeClient.CoreV1().Secrets("test").Get(context.Background(), "test", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"tls.crt": "new-cert", "tls.key": "new-key"}, secretDataToString(updated))
+		assert.Equal(t, v1.SecretTypeTLS, updated.Type)
+		assert.Equal(t, secret.Labels, updated.Labels)
+		assert.Equal(t, secret.Annotations, updated.Annotations)
+		assert.Equal(t, secret.OwnerReferences, updated.OwnerReferences)
+	})
+	t.Run("test UpdateSecret keeps other keys when merging", func(t *testing.T) {
+		err := k.UpdateSecret("test", map[string]string{"ca.crt": "ca"}, SecretOptions{Merge: true})
+		assert.Nil(t, err)
+		secrets, err := k.GetSecret("test")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"tls.crt": "new-cert", "tls.key": "new-key", "ca.crt": "ca"}, secrets)
+	})
+	t.Run("test UpdateSecret adds labels and annotations", func(t *testing.T) {
+		err := k.UpdateSecret("test", map[string]string{"tls.crt": "cert", "tls.key": "key"}, SecretOptions{
+			Labels:      map[string]string{"tier": "db"},
+			Annotations: map[string]string{"owner": "fred"},
+		})
+		assert.Nil(t, err)
+		updated, err := fakeClient.CoreV1().Secrets("test").Get(context.Background(), "test", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"app": "squirrel", "tier": "db"}, updated.Labels)
+		assert.Equal(t, map[string]string{"owner": "fred"}, updated.Annotations)
+	})
+	t.Run("test UpdateSecret fails when the data does not suit the secret type", func(t *testing.T) {
+		err := k.UpdateSecret("test", map[string]string{"tls.crt": "cert"}, SecretOptions{})
+		assert.NotNil(t, err)
+	})
+
+}
+
 func TestK8sGetSecret(t *testing.T) {
 
 	fakeClient := fake.NewSimpleClientset()
@@ -59,7 +192,7 @@ func TestK8sGetSecret(t *testing.T) {
 	}
 	wanted := mockSecretData()
 	t.Run("test GetSecret returns expected results", func(t *testing.T) {
-		err := k.CreateSecret("test", wanted, false)
+		err := k.CreateSecret("test", wanted, SecretOptions{})
 		assert.Nil(t, err)
 		secret, err := k.GetSecret("test")
 		assert.Nil(t, err)
@@ -68,6 +201,241 @@ func TestK8sGetSecret(t *testing.T) {
 
 }
 
+func TestK8sImmutableSecret(t *testing.T) {
+
+	fakeClient := fake.NewSimpleClientset()
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test CreateSecret creates an immutable secret", func(t *testing.T) {
+		err := k.CreateSecret("test", map[string]string{"tls.crt": "cert", "tls.key": "key"}, SecretOptions{
+			Type:      v1.SecretTypeTLS,
+			Labels:    map[string]string{"app": "squirrel"},
+			Immutable: true,
+		})
+		assert.Nil(t, err)
+		secret, err := fakeClient.CoreV1().Secrets("test").Get(context.Background(), "test", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.True(t, *secret.Immutable)
+	})
+	t.Run("test UpdateSecret and ApplySecret refuse to change an immutable secret", func(t *testing.T) {
+		err := k.UpdateSecret("test", map[string]string{"tls.crt": "new-cert", "tls.key": "new-key"}, SecretOptions{})
+		assert.True(t, IsImmutable(err))
+		err = k.ApplySecret("test", map[string]string{"tls.crt": "new-cert", "tls.key": "new-key"}, SecretOptions{})
+		assert.True(t, IsImmutable(err))
+		assert.False(t, IsImmutable(fmt.Errorf("squirrel")))
+	})
+	t.Run("test ReplaceSecret recreates the secret keeping its type and labels", func(t *testing.T) {
+		err := k.ReplaceSecret("test", map[string]string{"tls.crt": "new-cert", "tls.key": "new-key"}, SecretOptions{Immutable: true})
+		assert.Nil(t, err)
+		secret, err := fakeClient.CoreV1().Secrets("test").Get(context.Background(), "test", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"tls.crt": "new-cert", "tls.key": "new-key"}, secretDataToString(secret))
+		assert.Equal(t, v1.SecretTypeTLS, secret.Type)
+		assert.Equal(t, map[string]string{"app": "squirrel"}, secret.Labels)
+		assert.True(t, *secret.Immutable)
+	})
+	t.Run("test ReplaceSecret checks the keys of the kept type", func(t *testing.T) {
+		err := k.ReplaceSecret("test", mockSecretData(), SecretOptions{})
+		assert.NotNil(t, err)
+		_, err = k.GetSecret("test")
+		assert.Nil(t, err)
+	})
+	t.Run("test ReplaceSecret keeps an immutable secret immutable", func(t *testing.T) {
+		err := k.ReplaceSecret("test", map[string]string{"tls.crt": "newer-cert", "tls.key": "newer-key"}, SecretOptions{})
+		assert.Nil(t, err)
+		secret, err := fakeClient.CoreV1().Secrets("test").Get(context.Background(), "test", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "newer-cert", string(secret.Data["tls.crt"]))
+		assert.NotNil(t, secret.Immutable)
+		assert.True(t, *secret.Immutable)
+	})
+
+}
+
+func TestK8sApplySecret(t *testing.T) {
+
+	fakeClient := fake.NewSimpleClientset()
+	var applied []k8stesting.PatchAction
+	fakeClient.PrependReactor("patch", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		applied = append(applied, patch)
+		return true, &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: patch.GetName(), Namespace: patch.GetNamespace()}}, nil
+	})
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test ApplySecret applies only the data and type given", func(t *testing.T) {
+		err := k.ApplySecret("test", map[string]string{"tls.crt": "cert", "tls.key": "key"}, SecretOptions{Type: v1.SecretTypeTLS})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(applied))
+		assert.Equal(t, types.ApplyPatchType, applied[0].GetPatchType())
+		assert.Equal(t, "test", applied[0].GetNamespace())
+		var secret v1.Secret
+		assert.Nil(t, json.Unmarshal(applied[0].GetPatch(), &secret))
+		assert.Equal(t, "test", secret.Name)
+		assert.Equal(t, v1.SecretTypeTLS, secret.Type)
+		assert.Equal(t, map[string]string{"tls.crt": "cert", "tls.key": "key"}, secretDataToString(&secret))
+		assert.Nil(t, secret.Labels)
+	})
+	t.Run("test ApplySecret leaves the type alone when not given", func(t *testing.T) {
+		err := k.ApplySecret("test", mockSecretData(), SecretOptions{})
+		assert.Nil(t, err)
+		assert.NotContains(t, string(applied[1].GetPatch()), `"type"`)
+	})
+	t.Run("test ApplySecret fails when required keys are missing", func(t *testing.T) {
+		err := k.ApplySecret("test", mockSecretData(), SecretOptions{Type: v1.SecretTypeTLS})
+		assert.NotNil(t, err)
+		assert.Equal(t, 2, len(applied))
+	})
+
+}
+
+func TestK8sSetSecretKeys(t *testing.T) {
+
+	secret := mockSecret(nil)
+	secret.Data = secretStringToBytes(map[string]string{"user": "squirrel", "passwd": "nuts", "host": "tree"})
+	secret.Labels = map[string]string{"app": "squirrel"}
+	fakeClient := fake.NewSimpleClientset(secret)
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test SetSecretKeys sets and removes only the given keys", func(t *testing.T) {
+		err := k.SetSecretKeys("test", map[string]string{"passwd": "acorns", "port": "80"}, []string{"host"})
+		assert.Nil(t, err)
+		secrets, err := k.GetSecret("test")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"user": "squirrel", "passwd": "acorns", "port": "80"}, secrets)
+		labels, err := k.GetSecretLabels("test")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"app": "squirrel"}, labels)
+	})
+	t.Run("test SetSecretKeys fails when secret not exists", func(t *testing.T) {
+		err := k.SetSecretKeys("missing", map[string]string{"passwd": "acorns"}, nil)
+		assert.True(t, kerr.IsNotFound(err))
+	})
+
+}
+
+func TestK8sCopySecret(t *testing.T) {
+
+	secret := mockSecret(nil)
+	secret.Data = secretStringToBytes(mockSecretData())
+	secret.Type = v1.SecretTypeTLS
+	secret.UID = "8f1c3b0e"
+	secret.ResourceVersion = "42"
+	secret.Labels = map[string]string{"app": "squirrel", "team": "nuts"}
+	secret.Annotations = map[string]string{"owner": "gerald", "kubectl.kubernetes.io/last-applied-configuration": "{}"}
+	from := &K8sClient{
+		client:    fake.NewSimpleClientset(secret),
+		namespace: "test",
+	}
+	to := &K8sClient{
+		client:    fake.NewSimpleClientset(),
+		namespace: "prod",
+	}
+	t.Run("test CopySecret keeps type, data and selected metadata", func(t *testing.T) {
+		err := from.CopySecret("test", to, "copied", CopyOptions{Labels: []string{"app"}, Annotations: []string{"owner"}})
+		assert.Nil(t, err)
+		copied, err := to.client.CoreV1().Secrets("prod").Get(context.Background(), "copied", metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, v1.SecretTypeTLS, copied.Type)
+		assert.Equal(t, mockSecretData(), secretDataToString(copied))
+		assert.Equal(t, map[string]string{"app": "squirrel"}, copied.Labels)
+		assert.Equal(t, map[string]string{"owner": "gerald"}, copied.Annotations)
+		assert.Empty(t, copied.UID)
+		assert.Empty(t, copied.ResourceVersion)
+	})
+	t.Run("test CopySecret keeps every label with a wildcard", func(t *testing.T) {
+		err := from.CopySecret("test", to, "labelled", CopyOptions{Labels: []string{"*"}})
+		assert.Nil(t, err)
+		labels, err := to.GetSecretLabels("labelled")
+		assert.Nil(t, err)
+		assert.Equal(t, secret.Labels, labels)
+	})
+	t.Run("test CopySecret only replaces an existing secret when overwriting", func(t *testing.T) {
+		err := from.CopySecret("test", to, "copied", CopyOptions{})
+		assert.True(t, kerr.IsAlreadyExists(err))
+		err = from.CopySecret("test", to, "copied", CopyOptions{Overwrite: true})
+		assert.Nil(t, err)
+	})
+
+}
+
+func TestK8sDeleteSecret(t *testing.T) {
+
+	fakeClient := fake.NewSimpleClientset(mockSecret(mockSecretData()))
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test DeleteSecret deletes the secret", func(t *testing.T) {
+		err := k.DeleteSecret("test")
+		assert.Nil(t, err)
+		_, err = k.GetSecret("test")
+		assert.True(t, kerr.IsNotFound(err))
+	})
+	t.Run("test DeleteSecret fails when secret not exists", func(t *testing.T) {
+		err := k.DeleteSecret("test")
+		assert.True(t, kerr.IsNotFound(err))
+	})
+
+}
+
+func TestK8sListSecrets(t *testing.T) {
+
+	labelled := func(ns, name string, labels map[string]string) *v1.Secret {
+		return &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: labels}}
+	}
+	token := labelled("test", "default-token", nil)
+	token.Type = v1.SecretTypeServiceAccountToken
+	fakeClient := fake.NewSimpleClientset(
+		labelled("test", "foo", map[string]string{"app": "squirrel"}),
+		labelled("test", "bar", map[string]string{"app": "nuts"}),
+		labelled("prod", "foo", map[string]string{"app": "squirrel"}),
+		token,
+	)
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test ListSecrets returns the secrets in the namespace", func(t *testing.T) {
+		secrets, err := k.ListSecrets("", false)
+		assert.Nil(t, err)
+		assert.Equal(t, []SecretRef{{"test", "bar"}, {"test", "foo"}}, secrets)
+	})
+	t.Run("test ListSecrets matches a selector across namespaces", func(t *testing.T) {
+		secrets, err := k.ListSecrets("app=squirrel", true)
+		assert.Nil(t, err)
+		assert.Equal(t, []SecretRef{{"prod", "foo"}, {"test", "foo"}}, secrets)
+	})
+
+}
+
+func TestK8sGetSecretLabels(t *testing.T) {
+
+	secret := mockSecret(mockSecretData())
+	secret.Labels = map[string]string{"app": "squirrel"}
+	fakeClient := fake.NewSimpleClientset(secret)
+	k := &K8sClient{
+		client:    fakeClient,
+		namespace: "test",
+	}
+	t.Run("test GetSecretLabels returns expected results", func(t *testing.T) {
+		labels, err := k.GetSecretLabels("test")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"app": "squirrel"}, labels)
+	})
+	t.Run("test GetSecretLabels fails when secret not exists", func(t *testing.T) {
+		_, err := k.GetSecretLabels("missing")
+		assert.True(t, kerr.IsNotFound(err))
+	})
+
+}
+
 func mockSecretData() map[string]string {
 	var secret = make(map[string]string)
 	secret["foo"] = "bar"
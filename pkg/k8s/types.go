@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultFieldManager is the field manager secrets are applied as with server-side apply.
+const DefaultFieldManager = "kubectl-ssm-secret"
+
+// SecretTypes maps the secret type names accepted on the command line to secret types.
+var SecretTypes = map[string]v1.SecretType{
+	"opaque":           v1.SecretTypeOpaque,
+	"tls":              v1.SecretTypeTLS,
+	"dockerconfigjson": v1.SecretTypeDockerConfigJson,
+	"basic-auth":       v1.SecretTypeBasicAuth,
+	"ssh-auth":         v1.SecretTypeSSHAuth,
+}
+
+// requiredKeys lists the keys a secret of each type must hold.
+var requiredKeys = map[v1.SecretType][]string{
+	v1.SecretTypeTLS:              {v1.TLSCertKey, v1.TLSPrivateKeyKey},
+	v1.SecretTypeDockerConfigJson: {v1.DockerConfigJsonKey},
+	v1.SecretTypeSSHAuth:          {v1.SSHAuthPrivateKey},
+}
+
+// SecretOptions controls how secrets are created and updated. Type defaults to Opaque
+// and only applies to new secrets; updates keep the type a secret has. Merge keeps the
+// keys of an updated secret that are not being written, instead of removing them.
+// FieldManager and Force are used by server-side apply. Labels and Annotations are set
+// on the secret, replacing the values of any it already has with the same keys.
+// Immutable marks new secrets immutable, so their data can never be changed in place.
+type SecretOptions struct {
+	Type         v1.SecretType
+	Merge        bool
+	FieldManager string
+	Force        bool
+	Labels       map[string]string
+	Annotations  map[string]string
+	Immutable    bool
+}
+
+// ImmutableError is returned when an immutable secret would be changed in place.
+type ImmutableError struct {
+	Name string
+}
+
+func (e *ImmutableError) Error() string {
+	return fmt.Sprintf("k8s: secret %s is immutable and cannot be changed in place", e.Name)
+}
+
+// IsImmutable reports whether err was returned because a secret is immutable.
+func IsImmutable(err error) bool {
+	var immutable *ImmutableError
+	return errors.As(err, &immutable)
+}
+
+// ParseSecretType returns the secret type for a name such as tls or basic-auth. Full
+// type names such as kubernetes.io/tls are accepted too.
+func ParseSecretType(name string) (v1.SecretType, error) {
+	if len(name) == 0 {
+		return v1.SecretTypeOpaque, nil
+	}
+	if stype, ok := SecretTypes[strings.ToLower(name)]; ok {
+		return stype, nil
+	}
+	var names []string
+	for n, stype := range SecretTypes {
+		if string(stype) == name {
+			return stype, nil
+		}
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("k8s: unknown secret type %q, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// ValidateSecret checks that secrets hold the keys their type requires.
+func ValidateSecret(stype v1.SecretType, secrets map[string]string) error {
+	var missing []string
+	for _, key := range requiredKeys[stype] {
+		if _, ok := secrets[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("k8s: secret of type %s is missing required keys: %s", stype, strings.Join(missing, ", "))
+	}
+	switch stype {
+	case v1.SecretTypeBasicAuth:
+		_, user := secrets[v1.BasicAuthUsernameKey]
+		_, passwd := secrets[v1.BasicAuthPasswordKey]
+		if !user && !passwd {
+			return fmt.Errorf("k8s: secret of type %s needs at least one of the keys: %s, %s", stype, v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey)
+		}
+	case v1.SecretTypeDockerConfigJson:
+		if !json.Valid([]byte(secrets[v1.DockerConfigJsonKey])) {
+			return fmt.Errorf("k8s: key %s of secret of type %s is not valid json", v1.DockerConfigJsonKey, stype)
+		}
+	}
+	return nil
+}
+
+// ValidateMetadata checks labels and annotations against the api server's rules.
+func ValidateMetadata(labels map[string]string, annotations map[string]string) error {
+	for k, v := range labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("k8s: invalid label key %q: %s", k, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("k8s: invalid value %q for label %s: %s", v, k, strings.Join(errs, "; "))
+		}
+	}
+	for k := range annotations {
+		if errs := validation.IsQualifiedName(strings.ToLower(k)); len(errs) > 0 {
+			return fmt.Errorf("k8s: invalid annotation key %q: %s", k, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
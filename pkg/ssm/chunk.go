@@ -0,0 +1,160 @@
+package ssm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	// manifestPrefix marks a parameter value as the manifest of a chunked value.
+	manifestPrefix = "kubectl-ssm-secret/chunked:"
+	maxChunks      = 999
+	// maxGetParameters is the most names GetParameters accepts in one call.
+	maxGetParameters = 10
+)
+
+var chunkPattern = regexp.MustCompile(`^chunk-[0-9]{3}$`)
+
+// manifest records how a value too large for a single parameter was split. The chunk
+// versions are pinned so a manifest always reassembles to the value it was written with.
+type manifest struct {
+	Size     int     `json:"size"`
+	SHA256   string  `json:"sha256"`
+	Versions []int64 `json:"versions"`
+}
+
+// chunkName returns the name of the i'th chunk parameter of a chunked parameter.
+func chunkName(name string, i int) string {
+	return fmt.Sprintf("%s/chunk-%03d", name, i)
+}
+
+// isChunkName reports whether name is a chunk parameter of a chunked parameter.
+func isChunkName(name string) bool {
+	return chunkPattern.MatchString(path.Base(name))
+}
+
+func isManifest(value string) bool {
+	return strings.HasPrefix(value, manifestPrefix)
+}
+
+func checksum(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// splitValue splits value into chunks of at most size bytes without splitting a
+// multi-byte character across chunks.
+func splitValue(value string, size int) []string {
+	var chunks []string
+	for len(value) > size {
+		end := size
+		for end > 0 && !utf8.RuneStart(value[end]) {
+			end--
+		}
+		chunks = append(chunks, value[:end])
+		value = value[end:]
+	}
+	return append(chunks, value)
+}
+
+// chunkRequest splits a planned write whose value is too large for its tier into chunk
+// writes of the same type, leaving r to become the manifest once the chunks are written.
+func chunkRequest(r *PutRequest, chunkTier string) error {
+	pieces := splitValue(r.Value, tierLimit(chunkTier))
+	if len(pieces) > maxChunks {
+		return fmt.Errorf("ssm: %s needs %d chunks, at most %d are supported", r.Key, len(pieces), maxChunks)
+	}
+	for i, piece := range pieces {
+		r.Chunks = append(r.Chunks, &PutRequest{
+			Key:   r.Key,
+			Name:  chunkName(r.Name, i),
+			Value: piece,
+			Type:  r.Type,
+			Tier:  chunkTier,
+		})
+	}
+	return nil
+}
+
+// manifestValue builds the manifest for value from the versions its chunks were written as.
+func manifestValue(value string, versions []int64) (string, error) {
+	b, err := json.Marshal(manifest{
+		Size:     len(value),
+		SHA256:   checksum(value),
+		Versions: versions,
+	})
+	if err != nil {
+		return "", err
+	}
+	return manifestPrefix + string(b), nil
+}
+
+// reassemble replaces the manifest value of a chunked parameter with the value stored
+// across its chunks, verifying the size and checksum recorded in the manifest.
+func (c *Client) reassemble(ctx context.Context, param *Parameter) error {
+	var m manifest
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(param.Value, manifestPrefix)), &m); err != nil {
+		return fmt.Errorf("ssm: invalid chunk manifest in %s: %s", param.Name, err)
+	}
+	selectors := make([]string, len(m.Versions))
+	for i, version := range m.Versions {
+		selectors[i] = fmt.Sprintf("%s:%d", chunkName(param.Name, i), version)
+	}
+	chunks, err := c.getParametersBySelector(ctx, selectors)
+	if err != nil {
+		return err
+	}
+	var value strings.Builder
+	for i, selector := range selectors {
+		chunk, ok := chunks[chunkName(param.Name, i)]
+		if !ok {
+			return fmt.Errorf("ssm: chunk %s of %s is missing", selector, param.Name)
+		}
+		value.WriteString(aws.StringValue(chunk.Value))
+	}
+	if value.Len() != m.Size || checksum(value.String()) != m.SHA256 {
+		return fmt.Errorf("ssm: checksum mismatch reassembling %s from %d chunks", param.Name, len(m.Versions))
+	}
+	param.Value = value.String()
+	return nil
+}
+
+// getParametersBySelector reads parameters by name, where each name may carry a version
+// or label selector such as /foo/bar:3. Results are keyed by parameter name, without the
+// selector, and selectors that match nothing are left out.
+func (c *Client) getParametersBySelector(ctx context.Context, selectors []string) (map[string]*ssm.Parameter, error) {
+	results := make(map[string]*ssm.Parameter)
+	for start := 0; start < len(selectors); start += maxGetParameters {
+		end := start + maxGetParameters
+		if end > len(selectors) {
+			end = len(selectors)
+		}
+		input := &ssm.GetParametersInput{
+			Names:          aws.StringSlice(selectors[start:end]),
+			WithDecryption: aws.Bool(true),
+		}
+		var resp *ssm.GetParametersOutput
+		err := withThrottleRetry(ctx, func() error {
+			var err error
+			resp, err = c.GetParametersWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range resp.Parameters {
+			results[aws.StringValue(param.Name)] = param
+		}
+	}
+	return results, nil
+}
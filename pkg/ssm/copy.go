@@ -0,0 +1,91 @@
+package ssm
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// CopyOptions controls how parameters are copied between paths. Keys limits the copy to
+// the given secret keys. KeyId is the kms key used to encrypt SecureString parameters at
+// the destination; when empty, the source parameter's key is kept when copying with the
+// same client and the destination account's default key is used otherwise.
+type CopyOptions struct {
+	Recursive bool
+	KeyScheme string
+	Keys      []string
+	KeyId     string
+	Overwrite bool
+}
+
+// CopySecrets copies the parameters under from to the path to using the dest client,
+// keeping their type, tier, tags and description, and returns the names of the source
+// parameters copied. Chunked values are reassembled and chunked again at the destination.
+func (c *Client) CopySecrets(ctx context.Context, from string, dest *Client, to string, opts CopyOptions) ([]string, error) {
+	from = strings.TrimSuffix(from, "/")
+	to = strings.TrimSuffix(to, "/")
+	if dest == c && from == to {
+		return nil, fmt.Errorf("ssm: cannot copy path %s to itself", from)
+	}
+	params, err := c.GetSecrets(ctx, from, GetOptions{
+		Recursive:    opts.Recursive,
+		KeyScheme:    opts.KeyScheme,
+		WithMetadata: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, key := range opts.Keys {
+		wanted[key] = true
+	}
+	var selected Parameters
+	for _, param := range params {
+		if len(opts.Keys) == 0 || wanted[param.Key] {
+			selected = append(selected, param)
+			delete(wanted, param.Key)
+		}
+	}
+	if len(wanted) > 0 {
+		var missing []string
+		for key := range wanted {
+			missing = append(missing, key)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("ssm: keys not found under path %s: %s", from, strings.Join(missing, ", "))
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("ssm: no parameters found at path: %s", from)
+	}
+
+	var copied []string
+	for _, param := range selected {
+		tags, err := c.getTags(ctx, param.Name)
+		if err != nil {
+			return copied, err
+		}
+		keyId := opts.KeyId
+		if len(keyId) == 0 && dest == c {
+			keyId = param.KeyId
+		}
+		name := to + strings.TrimPrefix(param.Name, from)
+		err = dest.PutSecrets(path.Dir(name), map[string]string{path.Base(name): param.Value}, PutOptions{
+			Overwrite:   opts.Overwrite,
+			Tier:        param.Tier,
+			Chunk:       true,
+			KeyId:       keyId,
+			TypeRules:   []TypeRule{{Pattern: "*", Type: param.Type}},
+			Tags:        tags,
+			Description: param.Description,
+		})
+		if err != nil {
+			return copied, err
+		}
+		fmt.Printf("copied parameter: %s to %s\n", param.Name, name)
+		copied = append(copied, param.Name)
+	}
+	return copied, nil
+}
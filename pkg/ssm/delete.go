@@ -0,0 +1,105 @@
+package ssm
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// maxDeleteParameters is the most names DeleteParameters accepts in one call.
+const maxDeleteParameters = 10
+
+// ParameterNames returns the names of the parameters stored under parampath, sorted.
+// The chunk parameters of chunked values are included, so that deleting the names
+// leaves nothing behind, even when nested parameters are not.
+func (c *Client) ParameterNames(ctx context.Context, parampath string, recursive bool) ([]string, error) {
+	prefix := strings.TrimSuffix(parampath, "/") + "/"
+	var names []string
+	input := &ssm.GetParametersByPathInput{
+		Path:           aws.String(parampath),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(false),
+		MaxResults:     aws.Int64(maxPageSize),
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var resp *ssm.GetParametersByPathOutput
+		err := withThrottleRetry(ctx, func() error {
+			var err error
+			resp, err = c.GetParametersByPathWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, param := range resp.Parameters {
+			name := aws.StringValue(param.Name)
+			parent := strings.TrimPrefix(name, prefix)
+			if isChunkName(name) {
+				parent = path.Dir(parent)
+			}
+			if recursive || !strings.Contains(parent, "/") {
+				names = append(names, name)
+			}
+		}
+		if len(aws.StringValue(resp.NextToken)) == 0 {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// WithChunks returns names together with the chunk parameters in all that belong to any
+// of them, so that chunked parameters can be deleted without leaving chunks behind.
+func WithChunks(names, all []string) []string {
+	results := append([]string{}, names...)
+	parents := make(map[string]bool)
+	for _, name := range names {
+		parents[name] = true
+	}
+	for _, name := range all {
+		if isChunkName(name) && parents[path.Dir(name)] {
+			results = append(results, name)
+		}
+	}
+	sort.Strings(results)
+	return results
+}
+
+// DeleteSecrets deletes parameters by name, in batches of the most names a single
+// DeleteParameters call accepts.
+func (c *Client) DeleteSecrets(ctx context.Context, names []string) error {
+	for start := 0; start < len(names); start += maxDeleteParameters {
+		end := start + maxDeleteParameters
+		if end > len(names) {
+			end = len(names)
+		}
+		var resp *ssm.DeleteParametersOutput
+		err := withThrottleRetry(ctx, func() error {
+			var err error
+			resp, err = c.DeleteParametersWithContext(ctx, &ssm.DeleteParametersInput{
+				Names: aws.StringSlice(names[start:end]),
+			})
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range resp.DeletedParameters {
+			fmt.Printf("deleted parameter: %s\n", aws.StringValue(name))
+		}
+		if len(resp.InvalidParameters) > 0 {
+			return fmt.Errorf("ssm: could not delete parameters: %s", strings.Join(aws.StringValueSlice(resp.InvalidParameters), ", "))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package ssm
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+// GetHistory returns every version of a parameter, oldest first. Values are only
+// decrypted when decrypt is set.
+func (c *Client) GetHistory(ctx context.Context, name string, decrypt bool) ([]*Parameter, error) {
+	var results []*Parameter
+	input := &ssm.GetParameterHistoryInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(decrypt),
+		MaxResults:     aws.Int64(50),
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var resp *ssm.GetParameterHistoryOutput
+		err := withThrottleRetry(ctx, func() error {
+			var err error
+			resp, err = c.GetParameterHistoryWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range resp.Parameters {
+			results = append(results, newHistoryParameter(h))
+		}
+		if len(aws.StringValue(resp.NextToken)) == 0 {
+			break
+		}
+		input.NextToken = resp.NextToken
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Version < results[j].Version
+	})
+	return results, nil
+}
+
+func newHistoryParameter(h *ssm.ParameterHistory) *Parameter {
+	return &Parameter{
+		Name:             aws.StringValue(h.Name),
+		Value:            aws.StringValue(h.Value),
+		Type:             aws.StringValue(h.Type),
+		Version:          aws.Int64Value(h.Version),
+		LastModifiedDate: aws.TimeValue(h.LastModifiedDate),
+		DataType:         aws.StringValue(h.DataType),
+		KeyId:            aws.StringValue(h.KeyId),
+		Tier:             aws.StringValue(h.Tier),
+		Description:      aws.StringValue(h.Description),
+		LastModifiedUser: aws.StringValue(h.LastModifiedUser),
+		Labels:           aws.StringValueSlice(h.Labels),
+	}
+}
+
+// VersionAt returns the version from a parameter history, oldest first, that was
+// current at t, or nil when the parameter did not exist yet.
+func VersionAt(history []*Parameter, t time.Time) *Parameter {
+	var current *Parameter
+	for _, version := range history {
+		if version.LastModifiedDate.After(t) {
+			break
+		}
+		current = version
+	}
+	return current
+}
+
+// RestoreVersion writes the value of an earlier version of a parameter back as a new
+// version, keeping the type, kms key and description it had. The tier is given by
+// the caller, since a parameter cannot move from the Advanced tier back to Standard.
+func (c *Client) RestoreVersion(version *Parameter, tier string) (int64, error) {
+	pinput := &ssm.PutParameterInput{
+		Name:      aws.String(version.Name),
+		Type:      aws.String(version.Type),
+		Value:     aws.String(version.Value),
+		Overwrite: aws.Bool(true),
+		Tier:      aws.String(tier),
+	}
+	if version.Type == TypeSecureString && len(version.KeyId) > 0 {
+		pinput.KeyId = aws.String(version.KeyId)
+	}
+	if len(version.Description) > 0 {
+		pinput.Description = aws.String(version.Description)
+	}
+	resp, err := c.PutParameter(pinput)
+	if err != nil {
+		return 0, err
+	}
+	return aws.Int64Value(resp.Version), nil
+}
@@ -0,0 +1,278 @@
+package ssm
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+var (
+	// maxPageSize is the largest page GetParametersByPath will return.
+	maxPageSize int64 = 10
+
+	throttleRetries   = 8
+	throttleBaseDelay = 200 * time.Millisecond
+	throttleMaxDelay  = 10 * time.Second
+)
+
+// ParameterIterator pages through the parameters stored under a path. A page is only
+// requested from parameter store once the previous one has been consumed, so large
+// paths can be streamed without holding every parameter in memory.
+type ParameterIterator struct {
+	client     *Client
+	ctx        context.Context
+	input      *ssm.GetParametersByPathInput
+	parampath  string
+	recursive  bool
+	metadata   bool
+	split      bool
+	selector   string
+	separator  string
+	page       []*Parameter
+	param      *Parameter
+	names      map[string]string
+	collisions []string
+	missing    []string
+	done       bool
+	err        error
+}
+
+// NewParameterIterator returns an iterator over the parameters stored under parampath.
+func (c *Client) NewParameterIterator(ctx context.Context, parampath string, opts GetOptions) *ParameterIterator {
+	it := &ParameterIterator{
+		client:    c,
+		ctx:       ctx,
+		parampath: parampath,
+		recursive: opts.Recursive,
+		metadata:  opts.WithMetadata,
+		selector:  opts.Selector,
+		names:     make(map[string]string),
+	}
+	it.separator, it.err = keySeparator(opts.KeyScheme)
+
+	switch opts.StringLists {
+	case "", StringListJoin:
+	case StringListSplit:
+		it.split = true
+	default:
+		it.err = fmt.Errorf("ssm: unknown string list mode %q, must be one of %s or %s", opts.StringLists, StringListJoin, StringListSplit)
+	}
+
+	pageSize := opts.MaxResults
+	if pageSize == 0 {
+		pageSize = maxPageSize
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		it.err = fmt.Errorf("ssm: max results must be between 1 and %d, got %d", maxPageSize, opts.MaxResults)
+	}
+	it.input = &ssm.GetParametersByPathInput{
+		Path:           aws.String(parampath),
+		Recursive:      aws.Bool(opts.Recursive),
+		WithDecryption: aws.Bool(true),
+		MaxResults:     aws.Int64(pageSize),
+	}
+	return it
+}
+
+// Next advances the iterator to the next parameter, fetching a new page when required.
+// It returns false once the path is exhausted or an error occurs.
+func (it *ParameterIterator) Next() bool {
+	for {
+		for len(it.page) > 0 {
+			param := it.page[0]
+			it.page = it.page[1:]
+			if name, ok := it.names[param.Key]; ok {
+				it.collisions = append(it.collisions, fmt.Sprintf("%s and %s both map to key %s", name, param.Name, param.Key))
+				continue
+			}
+			it.names[param.Key] = param.Name
+			it.param = param
+			return true
+		}
+		if it.err != nil {
+			return false
+		}
+		if it.done {
+			if len(it.missing) > 0 {
+				sort.Strings(it.missing)
+				it.err = fmt.Errorf("ssm: no version matching %s under path %s for keys: %s", it.selector, it.parampath, strings.Join(it.missing, ", "))
+			} else if len(it.collisions) > 0 {
+				sort.Strings(it.collisions)
+				it.err = fmt.Errorf("ssm: key collisions under path %s:\n  %s", it.parampath, strings.Join(it.collisions, "\n  "))
+			}
+			return false
+		}
+		it.fetch()
+	}
+}
+
+func (it *ParameterIterator) fetch() {
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return
+	}
+	var resp *ssm.GetParametersByPathOutput
+	err := withThrottleRetry(it.ctx, func() error {
+		var err error
+		resp, err = it.client.GetParametersByPathWithContext(it.ctx, it.input)
+		return err
+	})
+	if err != nil {
+		it.err = err
+		return
+	}
+	page := make([]*Parameter, 0, len(resp.Parameters))
+	for _, param := range resp.Parameters {
+		if it.recursive && isChunkName(*param.Name) {
+			continue
+		}
+		key := secretKey(it.parampath, *param.Name, it.recursive, it.separator)
+		page = append(page, newParameter(key, param))
+	}
+	if len(it.selector) > 0 {
+		if page, err = it.resolve(page); err != nil {
+			it.err = err
+			return
+		}
+	}
+	for _, p := range page {
+		if isManifest(p.Value) {
+			if err := it.client.reassemble(it.ctx, p); err != nil {
+				it.err = err
+				return
+			}
+		}
+	}
+	if it.metadata {
+		if err := it.client.describe(it.ctx, page); err != nil {
+			it.err = err
+			return
+		}
+	}
+	it.page = page
+	if it.split {
+		it.page = nil
+		for _, param := range page {
+			if param.Type == TypeStringList {
+				it.page = append(it.page, splitStringList(param, it.separator)...)
+				continue
+			}
+			it.page = append(it.page, param)
+		}
+	}
+	if len(aws.StringValue(resp.NextToken)) == 0 {
+		it.done = true
+		return
+	}
+	it.input.NextToken = resp.NextToken
+}
+
+// resolve replaces each parameter with the version matching the iterator's selector.
+// Parameters without a matching version are recorded as missing and dropped.
+func (it *ParameterIterator) resolve(page []*Parameter) ([]*Parameter, error) {
+	selectors := make([]string, len(page))
+	for i, param := range page {
+		selectors[i] = param.Name + ":" + it.selector
+	}
+	selected, err := it.client.getParametersBySelector(it.ctx, selectors)
+	if err != nil {
+		return nil, err
+	}
+	var resolved []*Parameter
+	for _, param := range page {
+		match, ok := selected[param.Name]
+		if !ok {
+			it.missing = append(it.missing, param.Key)
+			continue
+		}
+		resolved = append(resolved, newParameter(param.Key, match))
+	}
+	return resolved, nil
+}
+
+// Parameter returns the current parameter.
+func (it *ParameterIterator) Parameter() *Parameter {
+	return it.param
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *ParameterIterator) Err() error {
+	return it.err
+}
+
+// describe fills in the metadata only DescribeParameters returns, such as the KMS key.
+func (c *Client) describe(ctx context.Context, params []*Parameter) error {
+	if len(params) == 0 {
+		return nil
+	}
+	byName := make(map[string]*Parameter)
+	var names []*string
+	for _, param := range params {
+		byName[param.Name] = param
+		names = append(names, aws.String(param.Name))
+	}
+	input := &ssm.DescribeParametersInput{
+		ParameterFilters: []*ssm.ParameterStringFilter{
+			{
+				Key:    aws.String("Name"),
+				Option: aws.String("Equals"),
+				Values: names,
+			},
+		},
+	}
+	for {
+		var resp *ssm.DescribeParametersOutput
+		err := withThrottleRetry(ctx, func() error {
+			var err error
+			resp, err = c.DescribeParametersWithContext(ctx, input)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, meta := range resp.Parameters {
+			if param, ok := byName[aws.StringValue(meta.Name)]; ok {
+				param.describe(meta)
+			}
+		}
+		if len(aws.StringValue(resp.NextToken)) == 0 {
+			return nil
+		}
+		input.NextToken = resp.NextToken
+	}
+}
+
+// withThrottleRetry calls fn, backing off exponentially with full jitter for as long
+// as parameter store responds with a ThrottlingException.
+func withThrottleRetry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || !isThrottled(err) || attempt >= throttleRetries {
+			return err
+		}
+		delay := throttleBaseDelay << uint(attempt)
+		if delay > throttleMaxDelay || delay <= 0 {
+			delay = throttleMaxDelay
+		}
+		delay = time.Duration(rand.Int63n(int64(delay))) + 1
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func isThrottled(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == "ThrottlingException"
+	}
+	return false
+}
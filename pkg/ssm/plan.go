@@ -0,0 +1,141 @@
+package ssm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	TierStandard           = "Standard"
+	TierAdvanced           = "Advanced"
+	TierIntelligentTiering = "Intelligent-Tiering"
+	// TierAuto picks the Standard tier for values that fit in it and Advanced otherwise.
+	TierAuto = "auto"
+
+	maxStandardSize = 4096
+	maxAdvancedSize = 8192
+)
+
+// PutRequest is a single parameter write planned by PlanSecrets. A value too large for
+// one parameter is written as Chunks, followed by a manifest parameter at Name.
+type PutRequest struct {
+	Key    string
+	Name   string
+	Value  string
+	Type   string
+	Tier   string
+	Chunks []*PutRequest
+}
+
+// Size returns the size of the value in bytes, as parameter store measures it.
+func (r *PutRequest) Size() int {
+	return len(r.Value)
+}
+
+// SizeError reports every planned value that is too large for its tier.
+type SizeError struct {
+	Oversized []*PutRequest
+}
+
+func (e *SizeError) Error() string {
+	lines := []string{fmt.Sprintf("ssm: %d values are too large to store:", len(e.Oversized))}
+	for _, r := range e.Oversized {
+		lines = append(lines, fmt.Sprintf("  %s: %d bytes, the %s tier limit is %d bytes", r.Key, r.Size(), r.Tier, tierLimit(r.Tier)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseTier converts a tier name given on the command line to a parameter store tier.
+func ParseTier(tier string) (string, error) {
+	for _, t := range []string{TierStandard, TierAdvanced, TierIntelligentTiering, TierAuto} {
+		if strings.EqualFold(tier, t) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("ssm: unknown tier %q, must be one of standard, advanced, intelligent-tiering or auto", tier)
+}
+
+func tierLimit(tier string) int {
+	if tier == TierStandard {
+		return maxStandardSize
+	}
+	return maxAdvancedSize
+}
+
+// PlanSecrets works out the name, type and tier of every parameter PutSecrets would
+// write, and checks each value fits its tier so nothing is written when any key would
+// be rejected. With opts.Chunk set, values too large for their tier are split into
+// chunks instead of being rejected.
+func PlanSecrets(parampath string, secrets map[string]string, opts PutOptions) ([]*PutRequest, error) {
+	if err := ValidateTags(opts.Tags); err != nil {
+		return nil, err
+	}
+	if err := ValidateLabels(opts.Labels); err != nil {
+		return nil, err
+	}
+	if len(opts.Description) > maxDescriptionLength {
+		return nil, fmt.Errorf("ssm: description is longer than %d characters", maxDescriptionLength)
+	}
+	tier := opts.Tier
+	if len(tier) == 0 {
+		tier = TierAuto
+	}
+	tier, err := ParseTier(tier)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for k := range secrets {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var plan []*PutRequest
+	var oversized []*PutRequest
+	for _, k := range keys {
+		v := secrets[k]
+		if len(v) < 1 {
+			fmt.Printf("warn: secret key %s has no value, ignoring\n", k)
+			continue
+		}
+		r := &PutRequest{
+			Key:   k,
+			Name:  parampath + "/" + k,
+			Value: v,
+			Type:  parameterType(k, opts.TypeRules),
+			Tier:  tier,
+		}
+		if r.Tier == TierAuto {
+			r.Tier = TierStandard
+			if r.Size() > maxStandardSize || len(opts.Policies) > 0 {
+				r.Tier = TierAdvanced
+			}
+		}
+		if r.Tier == TierStandard && len(opts.Policies) > 0 {
+			r.Tier = TierAdvanced
+		}
+		if r.Size() > tierLimit(r.Tier) {
+			if !opts.Chunk {
+				oversized = append(oversized, r)
+				continue
+			}
+			if tier == TierAuto {
+				// the chunks and the small manifest fit the cheapest tier the policies allow
+				r.Tier = TierStandard
+				if len(opts.Policies) > 0 {
+					r.Tier = TierAdvanced
+				}
+			}
+			if err := chunkRequest(r, r.Tier); err != nil {
+				return nil, err
+			}
+		}
+		plan = append(plan, r)
+	}
+	if len(oversized) > 0 {
+		return nil, &SizeError{Oversized: oversized}
+	}
+	return plan, nil
+}
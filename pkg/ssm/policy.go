@@ -0,0 +1,104 @@
+package ssm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type policy struct {
+	Type       string            `json:"Type"`
+	Version    string            `json:"Version"`
+	Attributes map[string]string `json:"Attributes"`
+}
+
+// policyDuration is a duration in the units parameter policies accept, days or hours.
+type policyDuration struct {
+	value int
+	unit  string
+}
+
+func (d policyDuration) duration() time.Duration {
+	if d.unit == "Hours" {
+		return time.Duration(d.value) * time.Hour
+	}
+	return time.Duration(d.value) * 24 * time.Hour
+}
+
+// parsePolicyDuration parses durations such as 90d or 12h.
+func parsePolicyDuration(s string) (policyDuration, error) {
+	units := map[string]string{"d": "Days", "h": "Hours"}
+	if len(s) < 2 {
+		return policyDuration{}, fmt.Errorf("ssm: invalid policy duration %q, expected a number of days or hours such as 90d or 12h", s)
+	}
+	unit, ok := units[strings.ToLower(s[len(s)-1:])]
+	value, err := strconv.Atoi(s[:len(s)-1])
+	if !ok || err != nil || value < 1 {
+		return policyDuration{}, fmt.Errorf("ssm: invalid policy duration %q, expected a number of days or hours such as 90d or 12h", s)
+	}
+	return policyDuration{value: value, unit: unit}, nil
+}
+
+// BuildPolicies returns the parameter policy json for an expiration after expireAfter,
+// a notification notifyBefore the expiration and a notification when a parameter has
+// not changed for notifyNoChange. Durations are given in days or hours, e.g. 90d, and
+// empty durations are left out. An empty string is returned when no policy is requested.
+func BuildPolicies(now time.Time, expireAfter string, notifyBefore string, notifyNoChange string) (string, error) {
+	var policies []policy
+	if len(expireAfter) > 0 {
+		expire, err := parsePolicyDuration(expireAfter)
+		if err != nil {
+			return "", err
+		}
+		policies = append(policies, policy{
+			Type:    "Expiration",
+			Version: "1.0",
+			Attributes: map[string]string{
+				"Timestamp": now.Add(expire.duration()).UTC().Format(time.RFC3339),
+			},
+		})
+		if len(notifyBefore) > 0 {
+			before, err := parsePolicyDuration(notifyBefore)
+			if err != nil {
+				return "", err
+			}
+			if before.duration() >= expire.duration() {
+				return "", fmt.Errorf("ssm: expiration notification %s must be shorter than the expiration %s", notifyBefore, expireAfter)
+			}
+			policies = append(policies, policy{
+				Type:    "ExpirationNotification",
+				Version: "1.0",
+				Attributes: map[string]string{
+					"Before": strconv.Itoa(before.value),
+					"Unit":   before.unit,
+				},
+			})
+		}
+	} else if len(notifyBefore) > 0 {
+		return "", fmt.Errorf("ssm: an expiration notification requires an expiration")
+	}
+	if len(notifyNoChange) > 0 {
+		after, err := parsePolicyDuration(notifyNoChange)
+		if err != nil {
+			return "", err
+		}
+		policies = append(policies, policy{
+			Type:    "NoChangeNotification",
+			Version: "1.0",
+			Attributes: map[string]string{
+				"After": strconv.Itoa(after.value),
+				"Unit":  after.unit,
+			},
+		})
+	}
+	if len(policies) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(policies)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
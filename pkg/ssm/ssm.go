@@ -3,13 +3,17 @@ package ssm
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
@@ -17,64 +21,215 @@ import (
 
 var (
 	defaultRegion = "ap-southeast-2"
+
+	// KeySchemes maps the supported key flattening schemes to the separator used to
+	// join nested parameter path segments into a single secret key.
+	KeySchemes = map[string]string{
+		"underscore": "_",
+		"dot":        ".",
+		"dash":       "-",
+	}
 )
 
+// GetOptions controls how parameters under a path are read and turned into secret keys.
+// Selector pins every parameter to a version number or label instead of the latest
+// version.
+type GetOptions struct {
+	Recursive    bool
+	KeyScheme    string
+	MaxResults   int64
+	WithMetadata bool
+	StringLists  string
+	Selector     string
+}
+
+// PutOptions controls how secrets are written to parameter store. KeyId may be a KMS
+// key ID, ARN or alias and is used to encrypt SecureString parameters; when empty the
+// account's default aws/ssm key is used. Keys not matched by TypeRules are written as
+// SecureString. Tags and Description are applied to every parameter written. Policies
+// holds parameter policy json, which is only supported by the Advanced tier. Tier
+// defaults to TierAuto, which picks a tier for each key from the size of its value.
+// Chunk splits values too large for any tier across several parameters. Labels are
+// attached to every parameter version written.
+type PutOptions struct {
+	Overwrite   bool
+	Tier        string
+	Chunk       bool
+	KeyId       string
+	TypeRules   []TypeRule
+	Tags        map[string]string
+	Description string
+	Policies    string
+	Labels      []string
+}
+
+// Parameter is a parameter store value along with the metadata describing it. KeyId,
+// Tier, Description and LastModifiedUser are only known when read with metadata or
+// from the parameter history, and Labels only from the history.
+type Parameter struct {
+	Key              string
+	Name             string
+	Value            string
+	Type             string
+	Version          int64
+	LastModifiedDate time.Time
+	ARN              string
+	DataType         string
+	KeyId            string
+	Tier             string
+	Description      string
+	LastModifiedUser string
+	Labels           []string
+}
+
+// Parameters is the set of parameters read from a parameter store path.
+type Parameters []*Parameter
+
+// Secrets returns the parameter values as a map of secret keys to values.
+func (p Parameters) Secrets() map[string]string {
+	results := make(map[string]string)
+	for _, param := range p {
+		results[param.Key] = param.Value
+	}
+	return results
+}
+
+func newParameter(key string, param *ssm.Parameter) *Parameter {
+	return &Parameter{
+		Key:              key,
+		Name:             aws.StringValue(param.Name),
+		Value:            aws.StringValue(param.Value),
+		Type:             aws.StringValue(param.Type),
+		Version:          aws.Int64Value(param.Version),
+		LastModifiedDate: aws.TimeValue(param.LastModifiedDate),
+		ARN:              aws.StringValue(param.ARN),
+		DataType:         aws.StringValue(param.DataType),
+	}
+}
+
+func (p *Parameter) describe(meta *ssm.ParameterMetadata) {
+	p.KeyId = aws.StringValue(meta.KeyId)
+	p.Tier = aws.StringValue(meta.Tier)
+	p.Description = aws.StringValue(meta.Description)
+	p.LastModifiedUser = aws.StringValue(meta.LastModifiedUser)
+}
+
 type Client struct {
 	ssmiface.SSMAPI
+	region string
 }
 
 func New() (*Client, error) {
-	svc := ssm.New(Sess())
+	sess := Sess()
+	svc := ssm.New(sess)
 	return &Client{
-		svc,
+		SSMAPI: svc,
+		region: aws.StringValue(sess.Config.Region),
 	}, nil
 }
 
+// Region returns the aws region the client reads and writes parameters in.
+func (c *Client) Region() string {
+	return c.region
+}
+
 //Sess init new config session
 func Sess() *session.Session {
-	awsRegion := os.Getenv("AWS_REGION")
-	if awsRegion == "" {
-		awsRegion = os.Getenv("AWS_DEFAULT_REGION")
-	}
-	if awsRegion == "" {
-		awsRegion = defaultRegion
-	}
 	sess := session.Must(session.NewSessionWithOptions(session.Options{
-		Config:            aws.Config{Region: aws.String(awsRegion)},
+		Config:            aws.Config{Region: aws.String(envRegion())},
 		SharedConfigState: session.SharedConfigEnable,
 	}))
 	return sess
 }
 
-// GetSecrets queries ssm parameter store for a given path and returns of map of key values.
-func (c *Client) GetSecrets(parampath string) (map[string]string, error) {
-	results := make(map[string]string)
+// SessionOptions selects the region and credentials of a session. Region falls back to
+// the environment, Profile to the default shared config profile, and RoleArn, when set,
+// is assumed using the credentials of the profile.
+type SessionOptions struct {
+	Region  string
+	Profile string
+	RoleArn string
+}
 
-	input := &ssm.GetParametersByPathInput{
-		Path:           aws.String(parampath),
-		Recursive:      aws.Bool(false),
-		WithDecryption: aws.Bool(true),
+// NewWithOptions returns a client for the region and credentials given by opts, such
+// as a client for another region or account to copy parameters to.
+func NewWithOptions(opts SessionOptions) (*Client, error) {
+	sess, err := SessWithOptions(opts)
+	if err != nil {
+		return nil, err
 	}
+	return &Client{
+		SSMAPI: ssm.New(sess),
+		region: aws.StringValue(sess.Config.Region),
+	}, nil
+}
 
-	for {
-
-		resp, err := c.GetParametersByPath(input)
-		if err != nil {
-			return nil, err
-		}
-
-		for _, param := range resp.Parameters {
-			_, key := path.Split(*param.Name)
-			results[key] = *param.Value
-		}
+// SessWithOptions returns a new config session for the region and credentials given by opts.
+func SessWithOptions(opts SessionOptions) (*session.Session, error) {
+	region := opts.Region
+	if region == "" {
+		region = envRegion()
+	}
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            aws.Config{Region: aws.String(region)},
+		Profile:           opts.Profile,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.RoleArn) > 0 {
+		sess = sess.Copy(&aws.Config{Credentials: stscreds.NewCredentials(sess, opts.RoleArn)})
+	}
+	return sess, nil
+}
 
-		if resp.NextToken == nil {
-			break
-		}
+func envRegion() string {
+	awsRegion := os.Getenv("AWS_REGION")
+	if awsRegion == "" {
+		awsRegion = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if awsRegion == "" {
+		awsRegion = defaultRegion
+	}
+	return awsRegion
+}
 
+// GetSecrets queries ssm parameter store for a given path and returns the parameters found.
+// When reading recursively, nested parameter names are flattened into keys using the
+// requested key scheme and any keys that collide are reported as an error.
+func (c *Client) GetSecrets(ctx context.Context, parampath string, opts GetOptions) (Parameters, error) {
+	var results Parameters
+	it := c.NewParameterIterator(ctx, parampath, opts)
+	for it.Next() {
+		results = append(results, it.Parameter())
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
 	}
 	return results, nil
+}
 
+// keySeparator returns the separator for a key scheme, defaulting to underscore.
+func keySeparator(scheme string) (string, error) {
+	if len(scheme) == 0 {
+		return KeySchemes["underscore"], nil
+	}
+	separator, ok := KeySchemes[scheme]
+	if !ok {
+		return "", fmt.Errorf("ssm: unknown key scheme %q, must be one of underscore, dot or dash", scheme)
+	}
+	return separator, nil
+}
+
+// secretKey derives the secret key for a parameter name read from parampath.
+func secretKey(parampath string, name string, recursive bool, separator string) string {
+	if !recursive {
+		_, key := path.Split(name)
+		return key
+	}
+	relative := strings.TrimPrefix(name, strings.TrimSuffix(parampath, "/")+"/")
+	return strings.ReplaceAll(relative, "/", separator)
 }
 
 // DecodeSecrets will convert from gzipped, base64 encoded values to strings.
@@ -129,34 +284,74 @@ func (c *Client) EncodeSecrets(secrets map[string]string) (map[string]string, er
 	return secrets, nil
 }
 
-func (c *Client) PutSecrets(parampath string, secrets map[string]string, overwrite bool, advanced bool) error {
-
-	for k, v := range secrets {
+// PutSecrets writes each secret key as a parameter under parampath. Every value is
+// checked against its tier before anything is written.
+func (c *Client) PutSecrets(parampath string, secrets map[string]string, opts PutOptions) error {
 
-		if len(v) < 1 {
-			fmt.Printf("warn: secret key %s has no value, ignoring\n", k)
-			continue
-		}
+	plan, err := PlanSecrets(parampath, secrets, opts)
+	if err != nil {
+		return err
+	}
 
-		tier := "Standard"
-		if advanced == true {
-			tier = "Advanced"
-		}
+	for _, r := range plan {
 
-		key := parampath + "/" + k
-		pinput := &ssm.PutParameterInput{
-			Name:      aws.String(key),
-			Type:      aws.String("SecureString"),
-			Value:     aws.String(v),
-			Overwrite: aws.Bool(overwrite),
-			Tier:      aws.String(tier),
+		value := r.Value
+		if len(r.Chunks) > 0 {
+			versions := make([]int64, 0, len(r.Chunks))
+			for _, chunk := range r.Chunks {
+				version, err := c.putRequest(chunk, chunk.Value, opts)
+				if err != nil {
+					return err
+				}
+				versions = append(versions, version)
+			}
+			value, err = manifestValue(r.Value, versions)
+			if err != nil {
+				return err
+			}
 		}
-		resp, err := c.PutParameter(pinput)
+		version, err := c.putRequest(r, value, opts)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("created parameter: %s, version: %d\n", key, *resp.Version)
+		if len(r.Chunks) > 0 {
+			fmt.Printf("created parameter: %s, type: %s, tier: %s, version: %d, chunks: %d\n", r.Name, r.Type, r.Tier, version, len(r.Chunks))
+			continue
+		}
+		fmt.Printf("created parameter: %s, type: %s, tier: %s, version: %d\n", r.Name, r.Type, r.Tier, version)
 
 	}
 	return nil
 }
+
+// putRequest writes a single planned parameter with the given value and returns the
+// version parameter store assigned to it.
+func (c *Client) putRequest(r *PutRequest, value string, opts PutOptions) (int64, error) {
+	pinput := &ssm.PutParameterInput{
+		Name:      aws.String(r.Name),
+		Type:      aws.String(r.Type),
+		Value:     aws.String(value),
+		Overwrite: aws.Bool(opts.Overwrite),
+		Tier:      aws.String(r.Tier),
+	}
+	if r.Type == TypeSecureString && len(opts.KeyId) > 0 {
+		pinput.KeyId = aws.String(opts.KeyId)
+	}
+	if len(opts.Description) > 0 {
+		pinput.Description = aws.String(opts.Description)
+	}
+	if len(opts.Policies) > 0 {
+		pinput.Policies = aws.String(opts.Policies)
+	}
+	resp, err := c.PutParameter(pinput)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.tagParameter(r.Name, opts.Tags); err != nil {
+		return 0, err
+	}
+	if err := c.labelParameter(r.Name, aws.Int64Value(resp.Version), opts.Labels); err != nil {
+		return 0, err
+	}
+	return aws.Int64Value(resp.Version), nil
+}
@@ -1,12 +1,17 @@
 package ssm
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/ssm"
 	"github.com/stretchr/testify/assert"
 )
@@ -30,6 +35,32 @@ var (
 			Version:          aws.Int64(1),
 		},
 	}
+	mockNestedParameters []*ssm.Parameter = []*ssm.Parameter{
+		&ssm.Parameter{
+			ARN:              aws.String("arn:aws:ssm:ap-southeast-2:012345678901:parameter/foo/db/user"),
+			LastModifiedDate: aws.Time(time.Now()),
+			Name:             aws.String("/foo/db/user"),
+			Type:             aws.String("SecureString"),
+			Value:            aws.String("DbUser"),
+			Version:          aws.Int64(1),
+		},
+		&ssm.Parameter{
+			ARN:              aws.String("arn:aws:ssm:ap-southeast-2:012345678901:parameter/foo/cache/user"),
+			LastModifiedDate: aws.Time(time.Now()),
+			Name:             aws.String("/foo/cache/user"),
+			Type:             aws.String("SecureString"),
+			Value:            aws.String("CacheUser"),
+			Version:          aws.Int64(1),
+		},
+		&ssm.Parameter{
+			ARN:              aws.String("arn:aws:ssm:ap-southeast-2:012345678901:parameter/foo/db.user"),
+			LastModifiedDate: aws.Time(time.Now()),
+			Name:             aws.String("/foo/db.user"),
+			Type:             aws.String("SecureString"),
+			Value:            aws.String("DottedUser"),
+			Version:          aws.Int64(1),
+		},
+	}
 	mockEncodedParameters []*ssm.Parameter = []*ssm.Parameter{
 		&ssm.Parameter{
 			ARN:              aws.String("arn:aws:ssm:ap-southeast-2:012345678901:parameter/foo/encoded"),
@@ -50,32 +81,415 @@ var (
 	}
 )
 
-func (m *Client) GetParametersByPath(i *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+var (
+	mockThrottles int
+	mockPageCalls int
+	mockPutInputs []*ssm.PutParameterInput
+	mockTagInputs []*ssm.AddTagsToResourceInput
+	// mockStore holds the version history of every parameter written under /store
+	mockStore = map[string][]*ssm.Parameter{}
+	// mockLabels maps parameter names to the version each label is attached to
+	mockLabels = map[string]map[string]int64{}
+)
+
+func mockStorePut(i *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
+	history := mockStore[*i.Name]
+	if len(history) > 0 && !aws.BoolValue(i.Overwrite) {
+		return nil, awserr.New(ssm.ErrCodeParameterAlreadyExists, "The parameter already exists.", nil)
+	}
+	param := &ssm.Parameter{
+		Name:             i.Name,
+		Type:             i.Type,
+		Value:            i.Value,
+		Version:          aws.Int64(int64(len(history) + 1)),
+		LastModifiedDate: aws.Time(time.Now()),
+	}
+	mockStore[*i.Name] = append(history, param)
+	return &ssm.PutParameterOutput{Version: param.Version}, nil
+}
+
+func mockStoreByPath(i *ssm.GetParametersByPathInput) (*ssm.GetParametersByPathOutput, error) {
+	prefix := strings.TrimSuffix(*i.Path, "/") + "/"
+	var names []string
+	for name := range mockStore {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if !aws.BoolValue(i.Recursive) && strings.Contains(strings.TrimPrefix(name, prefix), "/") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	resp := &ssm.GetParametersByPathOutput{}
+	for _, name := range names {
+		history := mockStore[name]
+		resp.Parameters = append(resp.Parameters, history[len(history)-1])
+	}
+	return resp, nil
+}
+
+func (m *Client) GetParametersWithContext(ctx aws.Context, i *ssm.GetParametersInput, opts ...request.Option) (*ssm.GetParametersOutput, error) {
+	// mock response/functionality
+	resp := &ssm.GetParametersOutput{}
+	for _, selector := range aws.StringValueSlice(i.Names) {
+		name, version := selector, int64(0)
+		if n := strings.LastIndex(selector, ":"); n > 0 {
+			name = selector[:n]
+			if _, err := fmt.Sscanf(selector[n+1:], "%d", &version); err != nil {
+				version = -1
+				if v, ok := mockLabels[name][selector[n+1:]]; ok {
+					version = v
+				}
+			}
+		}
+		var found *ssm.Parameter
+		for _, param := range mockStore[name] {
+			if version == 0 || *param.Version == version {
+				found = param
+			}
+		}
+		if found == nil {
+			resp.InvalidParameters = append(resp.InvalidParameters, aws.String(selector))
+			continue
+		}
+		resp.Parameters = append(resp.Parameters, found)
+	}
+	return resp, nil
+}
+
+func (m *Client) GetParametersByPathWithContext(ctx aws.Context, i *ssm.GetParametersByPathInput, opts ...request.Option) (*ssm.GetParametersByPathOutput, error) {
 	// mock response/functionality
+	if mockThrottles > 0 {
+		mockThrottles--
+		return nil, awserr.New("ThrottlingException", "Rate exceeded", nil)
+	}
+	if aws.StringValue(i.Path) == "/paged" {
+		// serve 25 parameters, honouring the requested page size
+		mockPageCalls++
+		start := 0
+		if i.NextToken != nil {
+			fmt.Sscanf(*i.NextToken, "%d", &start)
+		}
+		resp := &ssm.GetParametersByPathOutput{}
+		for n := start; n < 25 && n < start+int(*i.MaxResults); n++ {
+			resp.Parameters = append(resp.Parameters, &ssm.Parameter{
+				Name:  aws.String(fmt.Sprintf("/paged/key%02d", n)),
+				Type:  aws.String("SecureString"),
+				Value: aws.String(fmt.Sprintf("value%02d", n)),
+			})
+		}
+		if start+int(*i.MaxResults) < 25 {
+			resp.NextToken = aws.String(fmt.Sprintf("%d", start+int(*i.MaxResults)))
+		}
+		return resp, nil
+	}
+	if strings.HasPrefix(aws.StringValue(i.Path), "/store") {
+		return mockStoreByPath(i)
+	}
+	if aws.StringValue(i.Path) == "/lists" {
+		return &ssm.GetParametersByPathOutput{
+			Parameters: []*ssm.Parameter{
+				&ssm.Parameter{Name: aws.String("/lists/hosts"), Type: aws.String("StringList"), Value: aws.String("a.example.com,b.example.com")},
+				&ssm.Parameter{Name: aws.String("/lists/port"), Type: aws.String("String"), Value: aws.String("5432")},
+			},
+		}, nil
+	}
+	params := append([]*ssm.Parameter{}, mockParameters...)
+	if aws.BoolValue(i.Recursive) {
+		params = append(params, mockNestedParameters...)
+	}
 	return &ssm.GetParametersByPathOutput{
-		Parameters: mockParameters,
+		Parameters: params,
 		NextToken:  nil,
 	}, nil
 }
 
+func (m *Client) DescribeParametersWithContext(ctx aws.Context, i *ssm.DescribeParametersInput, opts ...request.Option) (*ssm.DescribeParametersOutput, error) {
+	// mock response/functionality
+	resp := &ssm.DescribeParametersOutput{}
+	for _, name := range i.ParameterFilters[0].Values {
+		resp.Parameters = append(resp.Parameters, &ssm.ParameterMetadata{
+			Name:             name,
+			KeyId:            aws.String("alias/aws/ssm"),
+			Tier:             aws.String("Standard"),
+			LastModifiedUser: aws.String("arn:aws:iam::012345678901:user/gerald"),
+		})
+	}
+	return resp, nil
+}
+
 func (m *Client) PutParameter(i *ssm.PutParameterInput) (*ssm.PutParameterOutput, error) {
 	// mock response/functionality
+	mockPutInputs = append(mockPutInputs, i)
+	if strings.HasPrefix(*i.Name, "/store/") {
+		return mockStorePut(i)
+	}
 	return &ssm.PutParameterOutput{
 		Version: aws.Int64(1),
 	}, nil
 }
 
+func (m *Client) AddTagsToResource(i *ssm.AddTagsToResourceInput) (*ssm.AddTagsToResourceOutput, error) {
+	// mock response/functionality
+	mockTagInputs = append(mockTagInputs, i)
+	return &ssm.AddTagsToResourceOutput{}, nil
+}
+
+func (m *Client) LabelParameterVersion(i *ssm.LabelParameterVersionInput) (*ssm.LabelParameterVersionOutput, error) {
+	// mock response/functionality
+	resp := &ssm.LabelParameterVersionOutput{ParameterVersion: i.ParameterVersion}
+	if _, ok := mockLabels[*i.Name]; !ok {
+		mockLabels[*i.Name] = make(map[string]int64)
+	}
+	for _, label := range aws.StringValueSlice(i.Labels) {
+		if label == "rejected" {
+			resp.InvalidLabels = append(resp.InvalidLabels, aws.String(label))
+			continue
+		}
+		mockLabels[*i.Name][label] = *i.ParameterVersion
+	}
+	return resp, nil
+}
+
+func (m *Client) GetParameterHistoryWithContext(ctx aws.Context, i *ssm.GetParameterHistoryInput, opts ...request.Option) (*ssm.GetParameterHistoryOutput, error) {
+	// mock response/functionality, serving one version per page in reverse order
+	history, ok := mockStore[*i.Name]
+	if !ok {
+		return nil, awserr.New(ssm.ErrCodeParameterNotFound, "Parameter not found.", nil)
+	}
+	n := len(history) - 1
+	if i.NextToken != nil {
+		fmt.Sscanf(*i.NextToken, "%d", &n)
+	}
+	param := history[n]
+	h := &ssm.ParameterHistory{
+		Name:             param.Name,
+		Type:             param.Type,
+		Version:          param.Version,
+		LastModifiedDate: param.LastModifiedDate,
+		LastModifiedUser: aws.String("arn:aws:iam::012345678901:user/gerald"),
+		Tier:             aws.String("Standard"),
+	}
+	if aws.BoolValue(i.WithDecryption) {
+		h.Value = param.Value
+	}
+	for label, version := range mockLabels[*i.Name] {
+		if version == *param.Version {
+			h.Labels = append(h.Labels, aws.String(label))
+		}
+	}
+	resp := &ssm.GetParameterHistoryOutput{Parameters: []*ssm.ParameterHistory{h}}
+	if n > 0 {
+		resp.NextToken = aws.String(fmt.Sprintf("%d", n-1))
+	}
+	return resp, nil
+}
+
+func (m *Client) DeleteParametersWithContext(ctx aws.Context, i *ssm.DeleteParametersInput, opts ...request.Option) (*ssm.DeleteParametersOutput, error) {
+	// mock response/functionality
+	resp := &ssm.DeleteParametersOutput{}
+	for _, name := range i.Names {
+		if _, ok := mockStore[*name]; !ok {
+			resp.InvalidParameters = append(resp.InvalidParameters, name)
+			continue
+		}
+		delete(mockStore, *name)
+		resp.DeletedParameters = append(resp.DeletedParameters, name)
+	}
+	return resp, nil
+}
+
+func (m *Client) ListTagsForResourceWithContext(ctx aws.Context, i *ssm.ListTagsForResourceInput, opts ...request.Option) (*ssm.ListTagsForResourceOutput, error) {
+	// mock response/functionality, returning the tags added to the resource so far
+	tags := make(map[string]string)
+	for _, input := range mockTagInputs {
+		if *input.ResourceId == *i.ResourceId {
+			for _, tag := range input.Tags {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+	}
+	resp := &ssm.ListTagsForResourceOutput{}
+	for k, v := range tags {
+		resp.TagList = append(resp.TagList, &ssm.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+	return resp, nil
+}
+
 func TestSsmGetSecrets(t *testing.T) {
 	// Setup Test
 	mockssm := Client{}
 
 	t.Run("test GetSecrets returns expected results", func(t *testing.T) {
-		secrets, err := mockssm.GetSecrets("/foo")
+		params, err := mockssm.GetSecrets(context.Background(), "/foo", GetOptions{})
 		assert.Nil(t, err)
-		assert.Equal(t, 2, len(secrets))
+		assert.Equal(t, 2, len(params))
+		assert.Equal(t, "/foo/passwd", params[0].Name)
+		assert.Equal(t, "SecureString", params[0].Type)
+		assert.Equal(t, int64(1), params[0].Version)
+		assert.Equal(t, "", params[0].KeyId)
+		secrets := params.Secrets()
 		assert.Equal(t, "SecretSquirrel", secrets["passwd"], "SecretSquirrel")
 		assert.Equal(t, "Gerald", secrets["username"])
 	})
+
+	t.Run("test GetSecrets flattens nested keys when recursive", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/foo", GetOptions{Recursive: true})
+		assert.Nil(t, err)
+		assert.Equal(t, 5, len(params))
+		secrets := params.Secrets()
+		assert.Equal(t, "DbUser", secrets["db_user"])
+		assert.Equal(t, "CacheUser", secrets["cache_user"])
+		assert.Equal(t, "DottedUser", secrets["db.user"])
+	})
+
+	t.Run("test GetSecrets flattens nested keys with dash scheme", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/foo/", GetOptions{Recursive: true, KeyScheme: "dash"})
+		assert.Nil(t, err)
+		secrets := params.Secrets()
+		assert.Equal(t, "DbUser", secrets["db-user"])
+		assert.Equal(t, "CacheUser", secrets["cache-user"])
+	})
+
+	t.Run("test GetSecrets reports key collisions", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/foo", GetOptions{Recursive: true, KeyScheme: "dot"})
+		assert.Nil(t, params)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "/foo/db/user and /foo/db.user both map to key db.user")
+	})
+
+	t.Run("test GetSecrets includes metadata when requested", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/foo", GetOptions{WithMetadata: true})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(params))
+		for _, param := range params {
+			assert.Equal(t, "alias/aws/ssm", param.KeyId)
+			assert.Equal(t, "Standard", param.Tier)
+			assert.Equal(t, "arn:aws:iam::012345678901:user/gerald", param.LastModifiedUser)
+		}
+	})
+
+	t.Run("test GetSecrets rejects unknown key scheme", func(t *testing.T) {
+		_, err := mockssm.GetSecrets(context.Background(), "/foo", GetOptions{Recursive: true, KeyScheme: "slash"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestStringLists(t *testing.T) {
+	mockssm := Client{}
+
+	t.Run("test StringList parameters are kept comma joined by default", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/lists", GetOptions{})
+		assert.Nil(t, err)
+		secrets := params.Secrets()
+		assert.Equal(t, 2, len(secrets))
+		assert.Equal(t, "a.example.com,b.example.com", secrets["hosts"])
+	})
+
+	t.Run("test StringList parameters split into indexed keys", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/lists", GetOptions{StringLists: StringListSplit, KeyScheme: "dash"})
+		assert.Nil(t, err)
+		secrets := params.Secrets()
+		assert.Equal(t, 3, len(secrets))
+		assert.Equal(t, "a.example.com", secrets["hosts-0"])
+		assert.Equal(t, "b.example.com", secrets["hosts-1"])
+		assert.Equal(t, "5432", secrets["port"])
+	})
+
+	t.Run("test unknown StringList mode is rejected", func(t *testing.T) {
+		_, err := mockssm.GetSecrets(context.Background(), "/lists", GetOptions{StringLists: "explode"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestTypeRules(t *testing.T) {
+	mockssm := Client{}
+
+	t.Run("test ParseTypeRule accepts valid rules", func(t *testing.T) {
+		rule, err := ParseTypeRule("*_HOST=String")
+		assert.Nil(t, err)
+		assert.Equal(t, TypeRule{Pattern: "*_HOST", Type: TypeString}, rule)
+	})
+
+	t.Run("test ParseTypeRule rejects invalid rules", func(t *testing.T) {
+		for _, r := range []string{"*_HOST", "=String", "*_HOST=Secret", "[=String"} {
+			_, err := ParseTypeRule(r)
+			assert.NotNil(t, err, r)
+		}
+	})
+
+	t.Run("test PutSecrets applies the first matching type rule", func(t *testing.T) {
+		mockPutInputs = nil
+		rules := []TypeRule{
+			{Pattern: "*_HOST", Type: TypeString},
+			{Pattern: "DB_*", Type: TypeStringList},
+		}
+		secrets := map[string]string{
+			"DB_HOST":   "db.example.com",
+			"DB_PEERS":  "a,b",
+			"DB_PASSWD": "SecretSquirrel",
+		}
+		err := mockssm.PutSecrets("/foo", secrets, PutOptions{TypeRules: rules, KeyId: "alias/team-a"})
+		assert.Nil(t, err)
+		types := make(map[string]string)
+		for _, input := range mockPutInputs {
+			types[*input.Name] = *input.Type
+			if *input.Type != TypeSecureString {
+				assert.Nil(t, input.KeyId)
+			}
+		}
+		assert.Equal(t, TypeString, types["/foo/DB_HOST"])
+		assert.Equal(t, TypeStringList, types["/foo/DB_PEERS"])
+		assert.Equal(t, TypeStringList, types["/foo/DB_PASSWD"])
+	})
+}
+
+func TestParameterIterator(t *testing.T) {
+	mockssm := Client{}
+	throttleBaseDelay = time.Millisecond
+
+	t.Run("test iterator follows next token across pages", func(t *testing.T) {
+		mockPageCalls = 0
+		it := mockssm.NewParameterIterator(context.Background(), "/paged", GetOptions{MaxResults: 4})
+		var keys []string
+		for it.Next() {
+			keys = append(keys, it.Parameter().Key)
+		}
+		assert.Nil(t, it.Err())
+		assert.Equal(t, 25, len(keys))
+		assert.Equal(t, "key00", keys[0])
+		assert.Equal(t, "key24", keys[24])
+		assert.Equal(t, 7, mockPageCalls)
+	})
+
+	t.Run("test iterator rejects invalid max results", func(t *testing.T) {
+		it := mockssm.NewParameterIterator(context.Background(), "/paged", GetOptions{MaxResults: 50})
+		assert.False(t, it.Next())
+		assert.NotNil(t, it.Err())
+	})
+
+	t.Run("test iterator retries when throttled", func(t *testing.T) {
+		mockThrottles = 3
+		params, err := mockssm.GetSecrets(context.Background(), "/paged", GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 25, len(params))
+		assert.Equal(t, 0, mockThrottles)
+	})
+
+	t.Run("test iterator gives up when throttling persists", func(t *testing.T) {
+		mockThrottles = throttleRetries + 1
+		_, err := mockssm.GetSecrets(context.Background(), "/paged", GetOptions{})
+		assert.True(t, isThrottled(err))
+		mockThrottles = 0
+	})
+
+	t.Run("test iterator stops when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := mockssm.GetSecrets(ctx, "/paged", GetOptions{})
+		assert.Equal(t, context.Canceled, err)
+	})
 }
 
 func TestSession(t *testing.T) {
@@ -101,6 +515,23 @@ func TestSession(t *testing.T) {
 		assert.Equal(t, "ap-southeast-2", aws.StringValue(s.Config.Region))
 	})
 
+	t.Run("region option takes precedence over the environment", func(t *testing.T) {
+		os.Setenv("AWS_REGION", "us-east-1")
+		s, err := SessWithOptions(SessionOptions{Region: "eu-west-1"})
+		assert.Nil(t, err)
+		assert.Equal(t, "eu-west-1", aws.StringValue(s.Config.Region))
+		s, err = SessWithOptions(SessionOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, "us-east-1", aws.StringValue(s.Config.Region))
+		os.Unsetenv("AWS_REGION")
+	})
+
+	t.Run("client reports the region of its session", func(t *testing.T) {
+		c, err := NewWithOptions(SessionOptions{Region: "eu-west-1"})
+		assert.Nil(t, err)
+		assert.Equal(t, "eu-west-1", c.Region())
+	})
+
 }
 
 func TestEncodeDecode(t *testing.T) {
@@ -140,7 +571,7 @@ func TestSsmPutSecrets(t *testing.T) {
 	}
 
 	t.Run("test PutSecrets returns expected results", func(t *testing.T) {
-		err := mockssm.PutSecrets("/foo", mockSecrets, false, false)
+		err := mockssm.PutSecrets("/foo", mockSecrets, PutOptions{})
 		assert.Nil(t, err)
 	})
 
@@ -150,7 +581,7 @@ func TestSsmPutSecrets(t *testing.T) {
 		"null":   "",
 	}
 	t.Run("test PutSecrets with no value is ignored", func(t *testing.T) {
-		err := mockssm.PutSecrets("/foo", mockSecrets, false, false)
+		err := mockssm.PutSecrets("/foo", mockSecrets, PutOptions{})
 		assert.Nil(t, err)
 	})
 
@@ -159,7 +590,475 @@ func TestSsmPutSecrets(t *testing.T) {
 		"token":  strings.Repeat("*", 4100),
 	}
 	t.Run("test PutSecrets with secret size over 4 kb", func(t *testing.T) {
-		err := mockssm.PutSecrets("/foo", mockSecrets, false, true)
+		err := mockssm.PutSecrets("/foo", mockSecrets, PutOptions{Tier: TierAdvanced})
+		assert.Nil(t, err)
+	})
+
+	t.Run("test PutSecrets encrypts with the requested kms key", func(t *testing.T) {
+		mockPutInputs = nil
+		err := mockssm.PutSecrets("/foo", mockSecrets, PutOptions{KeyId: "alias/team-a"})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(mockPutInputs))
+		for _, input := range mockPutInputs {
+			assert.Equal(t, "alias/team-a", aws.StringValue(input.KeyId))
+		}
+	})
+
+	t.Run("test PutSecrets uses the default kms key when none is given", func(t *testing.T) {
+		mockPutInputs = nil
+		err := mockssm.PutSecrets("/foo", mockSecrets, PutOptions{})
+		assert.Nil(t, err)
+		for _, input := range mockPutInputs {
+			assert.Nil(t, input.KeyId)
+		}
+	})
+
+	t.Run("test PutSecrets tags and describes each parameter", func(t *testing.T) {
+		mockPutInputs, mockTagInputs = nil, nil
+		opts := PutOptions{
+			Tags:        map[string]string{"team": "squirrels", "kubectl-ssm-secret/secret": "foo"},
+			Description: "exported from secret default/foo",
+		}
+		err := mockssm.PutSecrets("/foo", mockSecrets, opts)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(mockTagInputs))
+		for _, input := range mockPutInputs {
+			assert.Equal(t, "exported from secret default/foo", aws.StringValue(input.Description))
+			assert.Nil(t, input.Tags)
+		}
+		for _, input := range mockTagInputs {
+			assert.Equal(t, "Parameter", aws.StringValue(input.ResourceType))
+			assert.Equal(t, 2, len(input.Tags))
+			assert.Equal(t, "kubectl-ssm-secret/secret", aws.StringValue(input.Tags[0].Key))
+			assert.Equal(t, "squirrels", aws.StringValue(input.Tags[1].Value))
+		}
+	})
+
+	t.Run("test PutSecrets skips tagging when there are no tags", func(t *testing.T) {
+		mockTagInputs = nil
+		err := mockssm.PutSecrets("/foo", mockSecrets, PutOptions{})
 		assert.Nil(t, err)
+		assert.Equal(t, 0, len(mockTagInputs))
+	})
+
+	t.Run("test PutSecrets rejects invalid tags before writing", func(t *testing.T) {
+		mockPutInputs = nil
+		err := mockssm.PutSecrets("/foo", mockSecrets, PutOptions{Tags: map[string]string{"team": "squirrels & co"}})
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, len(mockPutInputs))
+	})
+}
+
+func TestPlanSecrets(t *testing.T) {
+	mockssm := Client{}
+	mockSecrets := map[string]string{
+		"passwd": "SuperSecretSquirrelPassword",
+		"cert":   strings.Repeat("*", 5000),
+	}
+
+	t.Run("test PlanSecrets picks a tier per key", func(t *testing.T) {
+		plan, err := PlanSecrets("/foo", mockSecrets, PutOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(plan))
+		assert.Equal(t, "/foo/cert", plan[0].Name)
+		assert.Equal(t, TierAdvanced, plan[0].Tier)
+		assert.Equal(t, 5000, plan[0].Size())
+		assert.Equal(t, "/foo/passwd", plan[1].Name)
+		assert.Equal(t, TierStandard, plan[1].Tier)
+	})
+
+	t.Run("test PlanSecrets keeps intelligent tiering for every key", func(t *testing.T) {
+		plan, err := PlanSecrets("/foo", mockSecrets, PutOptions{Tier: TierIntelligentTiering})
+		assert.Nil(t, err)
+		for _, r := range plan {
+			assert.Equal(t, TierIntelligentTiering, r.Tier)
+		}
+	})
+
+	t.Run("test PlanSecrets normalises the tier name", func(t *testing.T) {
+		plan, err := PlanSecrets("/foo", mockSecrets, PutOptions{Tier: "advanced"})
+		assert.Nil(t, err)
+		for _, r := range plan {
+			assert.Equal(t, TierAdvanced, r.Tier)
+		}
+	})
+
+	t.Run("test PlanSecrets reports values too large for the standard tier", func(t *testing.T) {
+		_, err := PlanSecrets("/foo", mockSecrets, PutOptions{Tier: TierStandard})
+		assert.IsType(t, &SizeError{}, err)
+		assert.Contains(t, err.Error(), "cert: 5000 bytes, the Standard tier limit is 4096 bytes")
+	})
+
+	t.Run("test PutSecrets writes nothing when any value is over 8 KB", func(t *testing.T) {
+		mockPutInputs = nil
+		secrets := map[string]string{
+			"passwd":   "SuperSecretSquirrelPassword",
+			"keystore": strings.Repeat("*", 9000),
+			"bundle":   strings.Repeat("*", 8193),
+		}
+		err := mockssm.PutSecrets("/foo", secrets, PutOptions{})
+		assert.Equal(t, 0, len(mockPutInputs))
+		sizeErr, ok := err.(*SizeError)
+		assert.True(t, ok)
+		assert.Equal(t, 2, len(sizeErr.Oversized))
+		assert.Equal(t, "bundle", sizeErr.Oversized[0].Key)
+		assert.Equal(t, "keystore", sizeErr.Oversized[1].Key)
+	})
+
+	t.Run("test ParseTier accepts command line tier names", func(t *testing.T) {
+		tier, err := ParseTier("intelligent-tiering")
+		assert.Nil(t, err)
+		assert.Equal(t, TierIntelligentTiering, tier)
+		_, err = ParseTier("premium")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestChunkedSecrets(t *testing.T) {
+	mockssm := Client{}
+	bundle := strings.Repeat("-----BEGIN CERTIFICATE-----\nMIIC\u00e9\u4e16\n-----END CERTIFICATE-----\n", 200)
+	secrets := map[string]string{
+		"bundle": bundle,
+		"passwd": "SecretSquirrel",
+	}
+
+	t.Run("test PutSecrets chunks values over 8 KB", func(t *testing.T) {
+		mockPutInputs = nil
+		err := mockssm.PutSecrets("/store/chunked", secrets, PutOptions{Chunk: true})
+		assert.Nil(t, err)
+		chunks := (len(bundle) + maxStandardSize - 1) / maxStandardSize
+		assert.Equal(t, chunks+2, len(mockPutInputs))
+		for _, input := range mockPutInputs {
+			assert.Equal(t, TierStandard, aws.StringValue(input.Tier))
+			assert.LessOrEqual(t, len(aws.StringValue(input.Value)), maxStandardSize)
+		}
+		assert.Equal(t, 1, len(mockStore["/store/chunked/bundle/chunk-000"]))
+		assert.True(t, isManifest(*mockStore["/store/chunked/bundle"][0].Value))
+	})
+
+	t.Run("test GetSecrets reassembles chunked values", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/store/chunked", GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, secrets, params.Secrets())
+	})
+
+	t.Run("test GetSecrets skips chunks when recursive", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/store/chunked", GetOptions{Recursive: true})
+		assert.Nil(t, err)
+		assert.Equal(t, secrets, params.Secrets())
+	})
+
+	t.Run("test GetSecrets reads the chunk versions pinned by the manifest", func(t *testing.T) {
+		_, err := mockStorePut(&ssm.PutParameterInput{
+			Name:      aws.String("/store/chunked/bundle/chunk-001"),
+			Type:      aws.String("SecureString"),
+			Value:     aws.String("overwritten"),
+			Overwrite: aws.Bool(true),
+		})
+		assert.Nil(t, err)
+		params, err := mockssm.GetSecrets(context.Background(), "/store/chunked", GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, bundle, params.Secrets()["bundle"])
+	})
+
+	t.Run("test GetSecrets fails when a chunk does not match the checksum", func(t *testing.T) {
+		mockStore["/store/chunked/bundle/chunk-000"][0].Value = aws.String("corrupted")
+		_, err := mockssm.GetSecrets(context.Background(), "/store/chunked", GetOptions{})
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "checksum mismatch")
+	})
+
+	t.Run("test splitValue keeps multi-byte characters whole", func(t *testing.T) {
+		chunks := splitValue("ab\u4e16cd", 4)
+		assert.Equal(t, []string{"ab", "\u4e16c", "d"}, chunks)
+	})
+}
+
+func TestSelectors(t *testing.T) {
+	mockssm := Client{}
+	for _, v := range []string{"one", "two", "three"} {
+		mockssm.PutSecrets("/store/selected", map[string]string{"passwd": "passwd-" + v}, PutOptions{Overwrite: true})
+	}
+	mockssm.PutSecrets("/store/selected", map[string]string{"token": "token-one"}, PutOptions{})
+	mockLabels["/store/selected/passwd"] = map[string]int64{"prod-approved": 2}
+	mockLabels["/store/selected/token"] = map[string]int64{"prod-approved": 1}
+
+	t.Run("test GetSecrets reads a pinned version", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/store/selected", GetOptions{Selector: "1"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"passwd": "passwd-one", "token": "token-one"}, params.Secrets())
+		assert.Equal(t, int64(1), params[0].Version)
+	})
+
+	t.Run("test GetSecrets reads a labelled version", func(t *testing.T) {
+		params, err := mockssm.GetSecrets(context.Background(), "/store/selected", GetOptions{Selector: "prod-approved"})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{"passwd": "passwd-two", "token": "token-one"}, params.Secrets())
+	})
+
+	t.Run("test GetSecrets names keys without a matching version", func(t *testing.T) {
+		_, err := mockssm.GetSecrets(context.Background(), "/store/selected", GetOptions{Selector: "3"})
+		assert.NotNil(t, err)
+		assert.Equal(t, "ssm: no version matching 3 under path /store/selected for keys: token", err.Error())
+	})
+}
+
+func TestLabels(t *testing.T) {
+	mockssm := Client{}
+
+	t.Run("test PutSecrets labels every version written", func(t *testing.T) {
+		secrets := map[string]string{
+			"passwd": "SecretSquirrel",
+			"bundle": strings.Repeat("*", 9000),
+		}
+		err := mockssm.PutSecrets("/store/labelled", secrets, PutOptions{Chunk: true, Labels: []string{"release-2026-10-18", "cluster-blue"}})
+		assert.Nil(t, err)
+		for _, name := range []string{"/store/labelled/passwd", "/store/labelled/bundle", "/store/labelled/bundle/chunk-000", "/store/labelled/bundle/chunk-002"} {
+			assert.Equal(t, int64(1), mockLabels[name]["release-2026-10-18"], name)
+			assert.Equal(t, int64(1), mockLabels[name]["cluster-blue"], name)
+		}
+		err = mockssm.PutSecrets("/store/labelled", map[string]string{"passwd": "Gerald"}, PutOptions{Overwrite: true, Labels: []string{"cluster-blue"}})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), mockLabels["/store/labelled/passwd"]["cluster-blue"])
+		params, err := mockssm.GetSecrets(context.Background(), "/store/labelled", GetOptions{Selector: "release-2026-10-18"})
+		assert.Nil(t, err)
+		assert.Equal(t, secrets, params.Secrets())
+	})
+
+	t.Run("test PutSecrets reports labels parameter store rejects", func(t *testing.T) {
+		err := mockssm.PutSecrets("/store/labelled", map[string]string{"token": "Gerald"}, PutOptions{Labels: []string{"rejected"}})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("test ValidateLabels rejects invalid labels", func(t *testing.T) {
+		assert.Nil(t, ValidateLabels([]string{"release-2026-10-18", "cluster_blue", ".hidden"}))
+		for _, label := range []string{"2026-release", "aws-prod", "SSM", "prod/blue", strings.Repeat("l", 101), ""} {
+			assert.NotNil(t, ValidateLabels([]string{label}), label)
+		}
+	})
+}
+
+func TestGetHistory(t *testing.T) {
+	mockssm := Client{}
+	for _, v := range []string{"one", "two", "three"} {
+		mockssm.PutSecrets("/store/history", map[string]string{"passwd": "passwd-" + v}, PutOptions{Overwrite: true})
+	}
+	mockLabels["/store/history/passwd"] = map[string]int64{"prod-approved": 2}
+
+	t.Run("test GetHistory returns every version oldest first", func(t *testing.T) {
+		history, err := mockssm.GetHistory(context.Background(), "/store/history/passwd", true)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(history))
+		for i, version := range history {
+			assert.Equal(t, int64(i+1), version.Version)
+			assert.Equal(t, "arn:aws:iam::012345678901:user/gerald", version.LastModifiedUser)
+		}
+		assert.Equal(t, "passwd-one", history[0].Value)
+		assert.Equal(t, []string{"prod-approved"}, history[1].Labels)
+		assert.Equal(t, "passwd-three", history[2].Value)
+	})
+
+	t.Run("test GetHistory leaves values encrypted unless asked", func(t *testing.T) {
+		history, err := mockssm.GetHistory(context.Background(), "/store/history/passwd", false)
+		assert.Nil(t, err)
+		assert.Equal(t, "", history[0].Value)
+	})
+
+	t.Run("test GetHistory fails for unknown parameters", func(t *testing.T) {
+		_, err := mockssm.GetHistory(context.Background(), "/store/history/missing", false)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRollback(t *testing.T) {
+	mockssm := Client{}
+	for _, v := range []string{"one", "two", "three"} {
+		mockssm.PutSecrets("/store/rollback", map[string]string{"passwd": "passwd-" + v}, PutOptions{Overwrite: true})
+	}
+	for i, param := range mockStore["/store/rollback/passwd"] {
+		param.LastModifiedDate = aws.Time(time.Date(2026, 10, 1+i, 10, 0, 0, 0, time.UTC))
+	}
+	history, err := mockssm.GetHistory(context.Background(), "/store/rollback/passwd", true)
+	assert.Nil(t, err)
+
+	t.Run("test VersionAt picks the version current at a time", func(t *testing.T) {
+		assert.Nil(t, VersionAt(history, history[0].LastModifiedDate.Add(-time.Second)))
+		assert.Equal(t, int64(1), VersionAt(history, history[0].LastModifiedDate).Version)
+		assert.Equal(t, int64(3), VersionAt(history, time.Now().Add(time.Hour)).Version)
+	})
+
+	t.Run("test RestoreVersion writes an old value as a new version", func(t *testing.T) {
+		version, err := mockssm.RestoreVersion(history[0], TierStandard)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), version)
+		assert.Equal(t, "passwd-one", *mockStore["/store/rollback/passwd"][3].Value)
+	})
+
+	t.Run("test DeleteSecrets deletes parameters and reports unknown names", func(t *testing.T) {
+		err := mockssm.DeleteSecrets(context.Background(), []string{"/store/rollback/passwd"})
+		assert.Nil(t, err)
+		_, ok := mockStore["/store/rollback/passwd"]
+		assert.False(t, ok)
+		err = mockssm.DeleteSecrets(context.Background(), []string{"/store/rollback/passwd"})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestParameterNames(t *testing.T) {
+	mockssm := Client{}
+	mockssm.PutSecrets("/store/names", map[string]string{"bundle": strings.Repeat("x", 5000), "passwd": "x"}, PutOptions{Tier: TierStandard, Chunk: true})
+	mockssm.PutSecrets("/store/names/db", map[string]string{"user": "x"}, PutOptions{})
+
+	t.Run("test ParameterNames includes chunks but not nested parameters", func(t *testing.T) {
+		names, err := mockssm.ParameterNames(context.Background(), "/store/names", false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/store/names/bundle", "/store/names/bundle/chunk-000", "/store/names/bundle/chunk-001", "/store/names/passwd"}, names)
+	})
+
+	t.Run("test ParameterNames includes nested parameters when recursive", func(t *testing.T) {
+		names, err := mockssm.ParameterNames(context.Background(), "/store/names", true)
+		assert.Nil(t, err)
+		assert.Equal(t, 5, len(names))
+		assert.Equal(t, "/store/names/db/user", names[3])
+	})
+
+	t.Run("test WithChunks adds the chunks of the given parameters", func(t *testing.T) {
+		all, err := mockssm.ParameterNames(context.Background(), "/store/names", false)
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(WithChunks([]string{"/store/names/bundle"}, all)))
+		assert.Equal(t, []string{"/store/names/passwd"}, WithChunks([]string{"/store/names/passwd"}, all))
+	})
+}
+
+func TestCopySecrets(t *testing.T) {
+	mockssm := Client{}
+	mockssm.PutSecrets("/store/copy/src", map[string]string{"bundle": strings.Repeat("x", 5000), "host": "db.example.com", "passwd": "x"}, PutOptions{
+		Tier:      TierStandard,
+		Chunk:     true,
+		TypeRules: []TypeRule{{Pattern: "host", Type: TypeString}},
+		Tags:      map[string]string{"team": "squirrels"},
+	})
+	mockssm.PutSecrets("/store/copy/src/db", map[string]string{"user": "x"}, PutOptions{})
+
+	t.Run("test CopySecrets keeps type, tags and kms key", func(t *testing.T) {
+		mockPutInputs = nil
+		copied, err := mockssm.CopySecrets(context.Background(), "/store/copy/src", &mockssm, "/store/copy/dst", CopyOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/store/copy/src/bundle", "/store/copy/src/host", "/store/copy/src/passwd"}, copied)
+		assert.Equal(t, strings.Repeat("x", 4096), *mockStore["/store/copy/dst/bundle/chunk-000"][0].Value)
+		assert.Equal(t, "String", *mockStore["/store/copy/dst/host"][0].Type)
+		assert.Equal(t, "alias/aws/ssm", aws.StringValue(mockPutInputs[len(mockPutInputs)-1].KeyId))
+		tags, err := mockssm.getTags(context.Background(), "/store/copy/dst/passwd")
+		assert.Nil(t, err)
+		assert.Equal(t, "squirrels", tags["team"])
+	})
+
+	t.Run("test CopySecrets copies only selected keys and nested parameters", func(t *testing.T) {
+		copied, err := mockssm.CopySecrets(context.Background(), "/store/copy/src", &Client{}, "/store/copy/keys", CopyOptions{
+			Recursive: true,
+			Keys:      []string{"db_user", "passwd"},
+			KeyId:     "alias/prod",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/store/copy/src/db/user", "/store/copy/src/passwd"}, copied)
+		assert.Equal(t, "x", *mockStore["/store/copy/keys/db/user"][0].Value)
+		assert.Equal(t, "alias/prod", aws.StringValue(mockPutInputs[len(mockPutInputs)-1].KeyId))
+	})
+
+	t.Run("test CopySecrets fails for unknown keys and existing parameters", func(t *testing.T) {
+		_, err := mockssm.CopySecrets(context.Background(), "/store/copy/src", &mockssm, "/store/copy/keys", CopyOptions{Keys: []string{"missing"}})
+		assert.NotNil(t, err)
+		_, err = mockssm.CopySecrets(context.Background(), "/store/copy/src", &mockssm, "/store/copy/dst", CopyOptions{})
+		assert.NotNil(t, err)
+		_, err = mockssm.CopySecrets(context.Background(), "/store/copy/src", &mockssm, "/store/copy/src/", CopyOptions{})
+		assert.NotNil(t, err)
+	})
+	t.Run("test CopySecrets copies to the same path through another client", func(t *testing.T) {
+		copied, err := mockssm.CopySecrets(context.Background(), "/store/copy/src", &Client{}, "/store/copy/src", CopyOptions{Overwrite: true})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"/store/copy/src/bundle", "/store/copy/src/host", "/store/copy/src/passwd"}, copied)
+		assert.Equal(t, "db.example.com", *mockStore["/store/copy/src/host"][0].Value)
+	})
+}
+
+func TestChildPaths(t *testing.T) {
+	mockssm := Client{}
+	mockssm.PutSecrets("/store/tree/prod/db", map[string]string{"user": "x", "passwd": "x"}, PutOptions{})
+	mockssm.PutSecrets("/store/tree/prod/cache", map[string]string{"bundle": strings.Repeat("x", 5000)}, PutOptions{Tier: TierStandard, Chunk: true})
+	mockssm.PutSecrets("/store/tree/test/db", map[string]string{"user": "x"}, PutOptions{})
+	mockssm.PutSecrets("/store/tree", map[string]string{"stray": "x"}, PutOptions{})
+
+	t.Run("test ChildPaths returns the immediate child paths", func(t *testing.T) {
+		paths, err := mockssm.ChildPaths(context.Background(), "/store/tree/prod", 1)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"cache", "db"}, paths)
+	})
+
+	t.Run("test ChildPaths returns nested child paths", func(t *testing.T) {
+		paths, err := mockssm.ChildPaths(context.Background(), "/store/tree", 2)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"prod/cache", "prod/db", "test/db"}, paths)
+	})
+}
+
+func TestBuildPolicies(t *testing.T) {
+	now := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+
+	t.Run("test BuildPolicies returns nothing without policies", func(t *testing.T) {
+		policies, err := BuildPolicies(now, "", "", "")
+		assert.Nil(t, err)
+		assert.Equal(t, "", policies)
+	})
+
+	t.Run("test BuildPolicies builds all policies", func(t *testing.T) {
+		policies, err := BuildPolicies(now, "90d", "14d", "180d")
+		assert.Nil(t, err)
+		assert.JSONEq(t, `[
+			{"Type":"Expiration","Version":"1.0","Attributes":{"Timestamp":"2027-01-16T09:30:00Z"}},
+			{"Type":"ExpirationNotification","Version":"1.0","Attributes":{"Before":"14","Unit":"Days"}},
+			{"Type":"NoChangeNotification","Version":"1.0","Attributes":{"After":"180","Unit":"Days"}}
+		]`, policies)
+	})
+
+	t.Run("test BuildPolicies accepts hours", func(t *testing.T) {
+		policies, err := BuildPolicies(now, "", "", "12h")
+		assert.Nil(t, err)
+		assert.JSONEq(t, `[{"Type":"NoChangeNotification","Version":"1.0","Attributes":{"After":"12","Unit":"Hours"}}]`, policies)
+	})
+
+	t.Run("test BuildPolicies rejects invalid policies", func(t *testing.T) {
+		for _, p := range [][]string{{"90", "", ""}, {"90w", "", ""}, {"0d", "", ""}, {"", "14d", ""}, {"14d", "14d", ""}, {"", "", "d"}} {
+			_, err := BuildPolicies(now, p[0], p[1], p[2])
+			assert.NotNil(t, err, p)
+		}
+	})
+
+	t.Run("test PutSecrets uses the advanced tier for policies", func(t *testing.T) {
+		mockssm := Client{}
+		mockPutInputs = nil
+		policies, _ := BuildPolicies(now, "90d", "", "")
+		err := mockssm.PutSecrets("/foo", map[string]string{"passwd": "SecretSquirrel"}, PutOptions{Policies: policies})
+		assert.Nil(t, err)
+		assert.Equal(t, "Advanced", aws.StringValue(mockPutInputs[0].Tier))
+		assert.Equal(t, policies, aws.StringValue(mockPutInputs[0].Policies))
+	})
+}
+
+func TestValidateTags(t *testing.T) {
+	t.Run("test ValidateTags accepts label style tags", func(t *testing.T) {
+		assert.Nil(t, ValidateTags(map[string]string{"app.kubernetes.io/name": "foo", "owner": "team-a@example.com"}))
+	})
+
+	t.Run("test ValidateTags rejects too many tags", func(t *testing.T) {
+		tags := make(map[string]string)
+		for i := 0; i <= maxTags; i++ {
+			tags[fmt.Sprintf("tag%d", i)] = "value"
+		}
+		assert.NotNil(t, ValidateTags(tags))
+	})
+
+	t.Run("test ValidateTags rejects long keys and values", func(t *testing.T) {
+		assert.NotNil(t, ValidateTags(map[string]string{strings.Repeat("k", 129): "value"}))
+		assert.NotNil(t, ValidateTags(map[string]string{"key": strings.Repeat("v", 257)}))
 	})
 }
@@ -0,0 +1,124 @@
+package ssm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ssm"
+)
+
+const (
+	maxTags              = 50
+	maxTagKeyLength      = 128
+	maxTagValueLength    = 256
+	maxDescriptionLength = 1024
+	maxLabels            = 10
+)
+
+var (
+	tagPattern   = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+	labelPattern = regexp.MustCompile(`^[a-zA-Z._\-][a-zA-Z0-9._\-]{0,99}$`)
+)
+
+// ValidateTags checks tags against the limits parameter store enforces.
+func ValidateTags(tags map[string]string) error {
+	if len(tags) > maxTags {
+		return fmt.Errorf("ssm: %d tags requested, parameters can have at most %d", len(tags), maxTags)
+	}
+	for k, v := range tags {
+		if len(k) == 0 || len(k) > maxTagKeyLength || !tagPattern.MatchString(k) {
+			return fmt.Errorf("ssm: invalid tag key %q", k)
+		}
+		if len(v) > maxTagValueLength || !tagPattern.MatchString(v) {
+			return fmt.Errorf("ssm: invalid value %q for tag %s", v, k)
+		}
+	}
+	return nil
+}
+
+// ValidateLabels checks parameter version labels against the parameter store rules.
+func ValidateLabels(labels []string) error {
+	if len(labels) > maxLabels {
+		return fmt.Errorf("ssm: %d labels requested, at most %d can be attached at once", len(labels), maxLabels)
+	}
+	for _, label := range labels {
+		lower := strings.ToLower(label)
+		if !labelPattern.MatchString(label) || strings.HasPrefix(lower, "aws") || strings.HasPrefix(lower, "ssm") {
+			return fmt.Errorf("ssm: invalid label %q, labels can only contain letters, numbers, periods, hyphens and underscores, cannot start with a number and cannot start with aws or ssm", label)
+		}
+	}
+	return nil
+}
+
+// labelParameter attaches labels to a version of a parameter, moving them from any
+// other version of the parameter they were attached to.
+func (c *Client) labelParameter(name string, version int64, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	resp, err := c.LabelParameterVersion(&ssm.LabelParameterVersionInput{
+		Name:             aws.String(name),
+		ParameterVersion: aws.Int64(version),
+		Labels:           aws.StringSlice(labels),
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.InvalidLabels) > 0 {
+		return fmt.Errorf("ssm: could not label %s version %d with: %s", name, version, strings.Join(aws.StringValueSlice(resp.InvalidLabels), ", "))
+	}
+	return nil
+}
+
+// tagParameter adds tags to a parameter. Tags cannot be passed to PutParameter when
+// overwriting, so they are always added separately once the parameter is written.
+func (c *Client) tagParameter(name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	input := &ssm.AddTagsToResourceInput{
+		ResourceId:   aws.String(name),
+		ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+	}
+	for _, k := range keys {
+		input.Tags = append(input.Tags, &ssm.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(tags[k]),
+		})
+	}
+	_, err := c.AddTagsToResource(input)
+	return err
+}
+
+// getTags returns the tags of a parameter, leaving out the aws: tags only aws can set.
+func (c *Client) getTags(ctx context.Context, name string) (map[string]string, error) {
+	var resp *ssm.ListTagsForResourceOutput
+	err := withThrottleRetry(ctx, func() error {
+		var err error
+		resp, err = c.ListTagsForResourceWithContext(ctx, &ssm.ListTagsForResourceInput{
+			ResourceId:   aws.String(name),
+			ResourceType: aws.String(ssm.ResourceTypeForTaggingParameter),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for _, tag := range resp.TagList {
+		if strings.HasPrefix(aws.StringValue(tag.Key), "aws:") {
+			continue
+		}
+		tags[aws.StringValue(tag.Key)] = aws.StringValue(tag.Value)
+	}
+	return tags, nil
+}
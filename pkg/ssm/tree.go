@@ -0,0 +1,37 @@
+package ssm
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// ChildPaths returns the paths depth levels below parampath that have parameters stored
+// below them, sorted and relative to parampath. With a depth of 1, parameters stored as
+// /backup/db/user and /backup/cache/user give the child paths db and cache. Parameters
+// stored less than depth levels below parampath are not part of any child path.
+func (c *Client) ChildPaths(ctx context.Context, parampath string, depth int) ([]string, error) {
+	names, err := c.ParameterNames(ctx, parampath, true)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(parampath, "/") + "/"
+	seen := make(map[string]bool)
+	var results []string
+	for _, name := range names {
+		if isChunkName(name) {
+			continue
+		}
+		segments := strings.Split(strings.TrimPrefix(name, prefix), "/")
+		if len(segments) <= depth {
+			continue
+		}
+		child := strings.Join(segments[:depth], "/")
+		if !seen[child] {
+			seen[child] = true
+			results = append(results, child)
+		}
+	}
+	sort.Strings(results)
+	return results, nil
+}
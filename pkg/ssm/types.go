@@ -0,0 +1,66 @@
+package ssm
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	TypeString       = "String"
+	TypeStringList   = "StringList"
+	TypeSecureString = "SecureString"
+)
+
+// StringList handling modes used when reading StringList parameters.
+const (
+	StringListJoin  = "join"
+	StringListSplit = "split"
+)
+
+// TypeRule maps secret keys matching a glob pattern, such as *_HOST, to a parameter type.
+type TypeRule struct {
+	Pattern string
+	Type    string
+}
+
+// ParseTypeRule parses a type rule of the form PATTERN=TYPE, e.g. *_HOST=String.
+func ParseTypeRule(rule string) (TypeRule, error) {
+	parts := strings.SplitN(rule, "=", 2)
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return TypeRule{}, fmt.Errorf("ssm: invalid type rule %q, expected PATTERN=TYPE", rule)
+	}
+	if _, err := path.Match(parts[0], ""); err != nil {
+		return TypeRule{}, fmt.Errorf("ssm: invalid pattern in type rule %q: %s", rule, err)
+	}
+	switch parts[1] {
+	case TypeString, TypeStringList, TypeSecureString:
+	default:
+		return TypeRule{}, fmt.Errorf("ssm: invalid type in type rule %q, must be one of %s, %s or %s", rule, TypeString, TypeStringList, TypeSecureString)
+	}
+	return TypeRule{Pattern: parts[0], Type: parts[1]}, nil
+}
+
+// parameterType returns the type of the first rule matching key, or SecureString when
+// no rule matches.
+func parameterType(key string, rules []TypeRule) string {
+	for _, rule := range rules {
+		if ok, _ := path.Match(rule.Pattern, key); ok {
+			return rule.Type
+		}
+	}
+	return TypeSecureString
+}
+
+// splitStringList expands a StringList parameter into one parameter per list item,
+// keyed by the parameter key and the item's index.
+func splitStringList(param *Parameter, separator string) []*Parameter {
+	var results []*Parameter
+	for i, item := range strings.Split(param.Value, ",") {
+		split := *param
+		split.Key = fmt.Sprintf("%s%s%d", param.Key, separator, i)
+		split.Value = item
+		results = append(results, &split)
+	}
+	return results
+}